@@ -8,6 +8,8 @@ package reflect
 
 import "unsafe"
 
+func (fn *funcType) isVariadic() bool { return fn.OutLen&(1<<15) != 0 }
+
 func (fn *funcType) inParams() []*RType {
 	if fn.InLen == 0 {
 		return nil
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package model
+
+func (field *Field) IsAnonymous() bool {
+	return field.flags&isAnonymous != 0
+}
+
+func (field *Field) IsTime() bool {
+	return field.flags&isTime != 0
+}
+
+func (field *Field) IsSlice() bool {
+	return field.flags&isSlice != 0
+}
+
+func (field *Field) IsStruct() bool {
+	return field.flags&isStruct != 0
+}
+
+func (field *Field) IsMap() bool {
+	return field.flags&isMap != 0
+}
+
+func (field *Field) IsPointer() bool {
+	return field.flags&isPointer != 0
+}
+
+func (field *Field) HasRelation() bool {
+	return field.flags&isRelation != 0
+}
+
+func (field *Field) IsInterface() bool {
+	return field.flags&isInterface != 0
+}
+
+func (field *Field) IsSelfReference() bool {
+	return field.flags&isSelfReference != 0
+}
+
+// FieldByName returns the field of the model with the given name.
+func (model *Model) FieldByName(name string) (*Field, bool) {
+	field, ok := model.byName[name]
+	return field, ok
+}
+
+// HasMethod reports whether the model declares the white listed method name.
+func (model *Model) HasMethod(name string) bool {
+	return model.Methods[name]
+}
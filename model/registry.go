@@ -0,0 +1,135 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package model
+
+import (
+	"github.com/badu/reflect"
+)
+
+// NewRegistry returns an empty registry, which will look for the methods named in methodsLookup on every model.
+func NewRegistry(methodsLookup ...string) *Registry {
+	return &Registry{methodsLookup: methodsLookup}
+}
+
+// Scan discovers the models of the given components (structs or pointers to structs) and of their relations.
+func (r *Registry) Scan(components ...interface{}) error {
+	for _, component := range components {
+		if r.ModelOf(reflect.TypeOf(component)) == nil {
+			return ErrNotStruct
+		}
+	}
+	return nil
+}
+
+// ModelOf returns the model of the struct type t (or of the struct pointed by t), discovering it on first use.
+// It returns nil if t is not a struct.
+func (r *Registry) ModelOf(t *reflect.RType) *Model {
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Deref()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := r.models.Load(t); ok {
+		return cached.(*Model)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// another goroutine might have discovered it meanwhile
+	if cached, ok := r.models.Load(t); ok {
+		return cached.(*Model)
+	}
+	pending := make(map[*reflect.RType]*Model)
+	result := r.discover(t, pending)
+	// publish the new models only when they are complete
+	for typ, model := range pending {
+		r.models.Store(typ, model)
+	}
+	return result
+}
+
+// Range calls inspect for each discovered model, until inspect returns false.
+func (r *Registry) Range(inspect func(model *Model) bool) {
+	r.models.Range(func(_, value interface{}) bool {
+		return inspect(value.(*Model))
+	})
+}
+
+// discover builds the model of t and, recursively, of the models it relates to.
+// pending holds the models being built, so relation cycles point to the same model.
+func (r *Registry) discover(t *reflect.RType, pending map[*reflect.RType]*Model) *Model {
+	if cached, ok := r.models.Load(t); ok {
+		return cached.(*Model)
+	}
+	if model, ok := pending[t]; ok {
+		return model
+	}
+
+	plan := reflect.PlanOf(t)
+	model := &Model{Type: t, Plan: plan, Methods: make(map[string]bool), byName: make(map[string]*Field)}
+	pending[t] = model
+
+	ptrType := t.PtrTo()
+	for _, name := range r.methodsLookup {
+		if _, found := ptrType.MethodByName(name); found {
+			model.Methods[name] = true
+		}
+	}
+
+	for i := range plan.Fields {
+		planField := &plan.Fields[i]
+		if !planField.Exported {
+			continue
+		}
+		// fields shadowed by a shallower one with the same name are not reachable by name
+		if winner, ok := plan.Lookup(planField.Name); !ok || winner != planField {
+			continue
+		}
+		elem := planField.Type
+		if planField.Embedded && (elem.Kind() == reflect.Struct || elem.Kind() == reflect.Ptr && elem.Deref().Kind() == reflect.Struct) {
+			// its fields are promoted, so they follow in the plan
+			continue
+		}
+
+		field := &Field{PlanField: planField}
+		if planField.Depth > 0 {
+			field.flags |= isAnonymous
+		}
+		switch elem.Kind() {
+		case reflect.Ptr:
+			field.flags |= isPointer
+			elem = elem.Deref()
+		case reflect.Slice:
+			field.flags |= isSlice
+			elem = elem.ConvToSlice().ElemType
+			if elem.Kind() == reflect.Ptr {
+				field.flags |= isPointer
+				elem = elem.Deref()
+			}
+		case reflect.Map:
+			field.flags |= isMap
+		case reflect.Interface:
+			field.flags |= isInterface
+		}
+		if elem.Kind() == reflect.Struct {
+			field.flags |= isStruct
+			if elem == timeType {
+				field.flags |= isTime
+			} else {
+				field.flags |= isRelation
+				if elem == t {
+					field.flags |= isSelfReference
+				}
+				field.Relation = r.discover(elem, pending)
+			}
+		}
+		model.Fields = append(model.Fields, field)
+		model.byName[string(planField.Name)] = field
+	}
+	return model
+}
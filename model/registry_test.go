@@ -0,0 +1,133 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/badu/reflect"
+	"github.com/badu/reflect/model"
+)
+
+type (
+	Entity struct {
+		Id        uint64    `json:"id"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	Customer struct {
+		Entity
+		Name    string `json:"name"`
+		Users   []*User
+		Manager *User
+		Parent  *Customer
+		Tags    map[string]string
+		Extra   interface{}
+		secret  string
+	}
+
+	User struct {
+		Entity
+		Username string
+		Customer *Customer
+	}
+)
+
+func (e *Entity) BeforeSave() error { return nil }
+
+func TestRegistry(t *testing.T) {
+	registry := model.NewRegistry("BeforeSave", "Missing")
+	if err := registry.Scan(Customer{}); err != nil {
+		t.Fatalf("Scan: unexpected error %v", err)
+	}
+	if err := registry.Scan(&User{}, 1); err != model.ErrNotStruct {
+		t.Errorf("Scan: non struct component : got %v", err)
+	}
+
+	customer := registry.ModelOf(reflect.TypeOf(&Customer{}))
+	if customer == nil || customer != registry.ModelOf(reflect.TypeOf(Customer{})) {
+		t.Fatalf("ModelOf: got %v", customer)
+	}
+	names := []string{"Id", "CreatedAt", "Name", "Users", "Manager", "Parent", "Tags", "Extra"}
+	if len(customer.Fields) != len(names) {
+		t.Fatalf("Customer model: got %d fields, want %d", len(customer.Fields), len(names))
+	}
+	for i, name := range names {
+		if string(customer.Fields[i].Name) != name {
+			t.Errorf("Customer model: field %d : got %s, want %s", i, customer.Fields[i].Name, name)
+		}
+	}
+	if !customer.HasMethod("BeforeSave") || customer.HasMethod("Missing") {
+		t.Errorf("Customer model: methods : got %v", customer.Methods)
+	}
+
+	id, _ := customer.FieldByName("Id")
+	if !id.IsAnonymous() || id.HasRelation() {
+		t.Errorf("Customer model: Id flags")
+	}
+	if tag, ok := id.TagNamed([]byte("json")); !ok || string(tag) != "id" {
+		t.Errorf("Customer model: Id json tag : got %q %v", tag, ok)
+	}
+	createdAt, _ := customer.FieldByName("CreatedAt")
+	if !createdAt.IsTime() || createdAt.HasRelation() || createdAt.Relation != nil {
+		t.Errorf("Customer model: CreatedAt should be a time, not a relation")
+	}
+	users, _ := customer.FieldByName("Users")
+	manager, _ := customer.FieldByName("Manager")
+	user := registry.ModelOf(reflect.TypeOf(User{}))
+	if !users.IsSlice() || !users.IsPointer() || !users.HasRelation() || users.Relation != user || manager.Relation != user {
+		t.Errorf("Customer model: Users and Manager should relate to the User model")
+	}
+	if manager.IsSlice() || !manager.IsPointer() || !manager.IsStruct() {
+		t.Errorf("Customer model: Manager flags")
+	}
+	parent, _ := customer.FieldByName("Parent")
+	if !parent.IsSelfReference() || parent.Relation != customer {
+		t.Errorf("Customer model: Parent should be a self reference")
+	}
+	back, ok := user.FieldByName("Customer")
+	if !ok || back.Relation != customer || back.IsSelfReference() {
+		t.Errorf("User model: Customer should relate back to the Customer model")
+	}
+	tags, _ := customer.FieldByName("Tags")
+	extra, _ := customer.FieldByName("Extra")
+	if !tags.IsMap() || !extra.IsInterface() || tags.HasRelation() || extra.HasRelation() {
+		t.Errorf("Customer model: Tags and Extra flags")
+	}
+	if _, ok := customer.FieldByName("secret"); ok {
+		t.Errorf("Customer model: unexported fields should not be part of the model")
+	}
+
+	name, _ := customer.FieldByName("Name")
+	value := Customer{Name: "acme"}
+	if got := name.Value(reflect.ToStruct(reflect.ReflectOn(value))); got.String().Get() != "acme" {
+		t.Errorf("Customer model: Name value : got %q", got.String().Get())
+	}
+
+	count := 0
+	registry.Range(func(*model.Model) bool {
+		count++
+		return true
+	})
+	if count != 2 {
+		t.Errorf("Range: got %d models, want 2 (Customer and User)", count)
+	}
+
+	// concurrent discovery yields a single model
+	fresh := model.NewRegistry()
+	models := make(chan *model.Model, 8)
+	for i := 0; i < cap(models); i++ {
+		go func() { models <- fresh.ModelOf(reflect.TypeOf(User{})) }()
+	}
+	first := <-models
+	for i := 1; i < cap(models); i++ {
+		if m := <-models; m != first {
+			t.Errorf("ModelOf: concurrent discovery returned different models")
+		}
+	}
+}
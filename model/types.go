@@ -0,0 +1,65 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+// Package model discovers the models of an application (structs, their fields and the relations between them)
+// once per type, on top of the reflect package, and keeps them in a registry which is safe for concurrent use.
+package model
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/badu/reflect"
+)
+
+type (
+	fieldFlag uint16
+
+	// A Field is an exported field of a Model, promoted ones included.
+	// It shares the memory of the struct plan it comes from.
+	Field struct {
+		*reflect.PlanField
+		Relation *Model // the model of the struct (or of the slice of structs) held by the field, if any
+		flags    fieldFlag
+	}
+
+	// A Model describes a struct type : its fields, the models it relates to and the white listed methods it has.
+	// Models are immutable once discovered.
+	Model struct {
+		Type    *reflect.RType
+		Plan    *reflect.StructPlan
+		Fields  []*Field
+		Methods map[string]bool // the methods of the registry white list which are declared by the model (pointer receivers included)
+		byName  map[string]*Field
+	}
+
+	// A Registry discovers models and keeps them. It is safe for concurrent use.
+	Registry struct {
+		methodsLookup []string   // white list of methods searched on each model
+		mu            sync.Mutex // Guards discovery (but not loads) of models.
+		models        sync.Map   // map[*reflect.RType]*Model
+	}
+)
+
+const (
+	isAnonymous     fieldFlag = 1 << iota // promoted from an embedded struct
+	isTime                                // time.Time, *time.Time or a slice of them
+	isSlice                               // slice
+	isStruct                              // struct, pointer to struct or slice of them
+	isMap                                 // map
+	isPointer                             // pointer, or slice of pointers
+	isRelation                            // struct (other than time.Time), pointer to struct or slice of them
+	isInterface                           // interface
+	isSelfReference                       // relation to the model declaring the field
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+
+	// ErrNotStruct is returned by Scan for components which are neither structs nor pointers to structs.
+	ErrNotStruct = errors.New("model: component is not a struct")
+)
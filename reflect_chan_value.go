@@ -0,0 +1,252 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import "unsafe"
+
+// Len returns the number of elements queued (unread) in the channel buffer.
+func (v ChanValue) Len() int {
+	if !v.IsValid() {
+		return 0
+	}
+	return chanlen(v.pointer())
+}
+
+// Cap returns the channel buffer capacity, in units of elements.
+func (v ChanValue) Cap() int {
+	if !v.IsValid() {
+		return 0
+	}
+	return chancap(v.pointer())
+}
+
+// Close closes the channel v.
+// It fails if v is not exported or if it is a receive-only channel.
+func (v ChanValue) Close() bool {
+	if !v.IsValid() || !v.isExported() {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Close: channel must be exported")
+		}
+		return false
+	}
+	if ChanDir(v.Type.convToChan().dir)&SendDir == 0 {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Close: close of receive-only channel")
+		}
+		return false
+	}
+	chanclose(v.pointer())
+	return true
+}
+
+// Send sends x on the channel v, blocking until the value is sent.
+// As in Go, x's value must be assignable to the channel's element type.
+func (v ChanValue) Send(x Value) bool {
+	return v.send(x, false)
+}
+
+// TrySend attempts to send x on the channel v but will not block.
+// It reports whether the value was sent.
+// As in Go, x's value must be assignable to the channel's element type.
+func (v ChanValue) TrySend(x Value) bool {
+	return v.send(x, true)
+}
+
+// Recv receives and returns a value from the channel v, blocking until a value is ready.
+// The boolean value ok is true if the value corresponds to a send on the channel, false if it is a zero value received because the channel is closed.
+func (v ChanValue) Recv() (Value, bool) {
+	return v.recv(false)
+}
+
+// TryRecv attempts to receive a value from the channel v but will not block.
+// If the receive delivers a value, x is the transferred value and ok is true.
+// If the receive cannot finish without blocking, x is the zero Value and ok is false.
+// If the channel is closed, x is the zero value for the channel's element type and ok is false.
+func (v ChanValue) TryRecv() (Value, bool) {
+	return v.recv(true)
+}
+
+// internal send, possibly non-blocking.
+func (v ChanValue) send(x Value, nb bool) bool {
+	if !v.IsValid() || !v.isExported() {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Send: channel must be exported")
+		}
+		return false
+	}
+	chanType := v.Type.convToChan()
+	if ChanDir(chanType.dir)&SendDir == 0 {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Send: send on receive-only channel")
+		}
+		return false
+	}
+	// do not let unexported x leak
+	if !x.IsValid() || !x.isExported() {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Send: value must be exported")
+		}
+		return false
+	}
+	x = x.assignTo(chanType.ElemType, nil)
+	var elemPtr unsafe.Pointer
+	if x.isPointer() {
+		elemPtr = x.Ptr
+	} else {
+		elemPtr = unsafe.Pointer(&x.Ptr)
+	}
+	return chansend(v.pointer(), elemPtr, nb)
+}
+
+// internal recv, possibly non-blocking.
+func (v ChanValue) recv(nb bool) (Value, bool) {
+	if !v.IsValid() || !v.isExported() {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Recv: channel must be exported")
+		}
+		return Value{}, false
+	}
+	chanType := v.Type.convToChan()
+	if ChanDir(chanType.dir)&RecvDir == 0 {
+		if willPrintDebug {
+			panic("reflect.ChanValue.Recv: receive from send-only channel")
+		}
+		return Value{}, false
+	}
+	elemType := chanType.ElemType
+	result := Value{Type: elemType, Ptr: nil, Flag: Flag(elemType.Kind())}
+	var elemPtr unsafe.Pointer
+	if elemType.isDirectIface() {
+		elemPtr = unsafeNew(elemType)
+		result.Ptr = elemPtr
+		result.Flag |= pointerFlag
+	} else {
+		elemPtr = unsafe.Pointer(&result.Ptr)
+	}
+	selected, ok := chanrecv(v.pointer(), nb, elemPtr)
+	if !selected {
+		return Value{}, false
+	}
+	return result, ok
+}
+
+// Select executes a select operation described by the list of cases.
+// Like the Go select statement, it blocks until at least one of the cases can proceed, makes a uniform pseudo-random choice, and then executes that case.
+// It returns the index of the chosen case and, if that case was a receive operation, the value received and a boolean indicating whether the value corresponds to a send on the channel (as opposed to a zero value received because the channel is closed).
+// If the cases are malformed, Select returns -1.
+func Select(cases []SelectCase) (int, Value, bool) {
+	// NOTE: Do not trust that caller is not modifying cases data underfoot.
+	// The range is safe because the caller cannot modify our copy of the len and each iteration makes its own copy of the value c.
+	runCases := make([]runtimeSelect, len(cases))
+	haveDefault := false
+	for i, c := range cases {
+		runCase := &runCases[i]
+		runCase.dir = c.Dir
+		switch c.Dir {
+		case SelectDefault:
+			if haveDefault {
+				if willPrintDebug {
+					panic("reflect.Select: multiple default cases")
+				}
+				return -1, Value{}, false
+			}
+			haveDefault = true
+			if c.Chan.IsValid() {
+				if willPrintDebug {
+					panic("reflect.Select: default case has Chan value")
+				}
+				return -1, Value{}, false
+			}
+			if c.Send.IsValid() {
+				if willPrintDebug {
+					panic("reflect.Select: default case has Send value")
+				}
+				return -1, Value{}, false
+			}
+
+		case SelectSend:
+			ch := c.Chan
+			if !ch.IsValid() {
+				break
+			}
+			if ch.Kind() != Chan || !ch.isExported() {
+				if willPrintDebug {
+					panic("reflect.Select: SendDir case has non exported or non chan value")
+				}
+				return -1, Value{}, false
+			}
+			chanType := ch.Type.convToChan()
+			if ChanDir(chanType.dir)&SendDir == 0 {
+				if willPrintDebug {
+					panic("reflect.Select: SendDir case using recv-only channel")
+				}
+				return -1, Value{}, false
+			}
+			runCase.ch = ch.pointer()
+			runCase.typ = &chanType.RType
+			v := c.Send
+			if !v.IsValid() || !v.isExported() {
+				if willPrintDebug {
+					panic("reflect.Select: SendDir case missing or non exported Send value")
+				}
+				return -1, Value{}, false
+			}
+			v = v.assignTo(chanType.ElemType, nil)
+			if v.isPointer() {
+				runCase.val = v.Ptr
+			} else {
+				runCase.val = unsafe.Pointer(&v.Ptr)
+			}
+
+		case SelectRecv:
+			if c.Send.IsValid() {
+				if willPrintDebug {
+					panic("reflect.Select: RecvDir case has Send value")
+				}
+				return -1, Value{}, false
+			}
+			ch := c.Chan
+			if !ch.IsValid() {
+				break
+			}
+			if ch.Kind() != Chan || !ch.isExported() {
+				if willPrintDebug {
+					panic("reflect.Select: RecvDir case has non exported or non chan value")
+				}
+				return -1, Value{}, false
+			}
+			chanType := ch.Type.convToChan()
+			if ChanDir(chanType.dir)&RecvDir == 0 {
+				if willPrintDebug {
+					panic("reflect.Select: RecvDir case using send-only channel")
+				}
+				return -1, Value{}, false
+			}
+			runCase.ch = ch.pointer()
+			runCase.typ = &chanType.RType
+			runCase.val = unsafeNew(chanType.ElemType)
+
+		default:
+			if willPrintDebug {
+				panic("reflect.Select: invalid Dir")
+			}
+			return -1, Value{}, false
+		}
+	}
+
+	chosen, recvOK := rselect(runCases)
+	if runCases[chosen].dir != SelectRecv {
+		return chosen, Value{}, recvOK
+	}
+	elemType := runCases[chosen].typ.convToChan().ElemType
+	elemPtr := runCases[chosen].val
+	fl := Flag(elemType.Kind())
+	if elemType.isDirectIface() {
+		return chosen, Value{Type: elemType, Ptr: elemPtr, Flag: fl | pointerFlag}, recvOK
+	}
+	return chosen, Value{Type: elemType, Ptr: convPtr(elemPtr), Flag: fl}, recvOK
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// Clone returns a deep copy of v : structs, arrays, slices, maps, pointers and interfaces are copied recursively,
+// strings (being immutable) are shared. A pointer or map found more than once in v - including cycles -
+// is copied once, so the copy keeps the aliasing of the original. So is a backing array : the first slice met copies it
+// up to its capacity and the later slices lying within that capacity (like s[1:] or s[:2] after s) are slices
+// of the copy. A slice reaching outside of it (like s met after s[1:] or s[:2:2]) gets a copy of its own.
+// Funcs and channels are left nil, unless CloneShallowFuncs and CloneShallowChans are given.
+// Unexported fields are left zero, unless CloneUnexported is given : they are then copied as they are, without recursion,
+// since their content belongs to the package declaring them (think of a sync.Mutex or of the location of a time.Time).
+// The returned Value is addressable.
+func Clone(v Value, options ...CloneOption) Value {
+	if !v.IsValid() {
+		return Value{}
+	}
+	state := cloneState{copies: make(map[cloneKey]unsafe.Pointer)}
+	for _, option := range options {
+		state.options |= option
+	}
+	result := New(v.Type).Deref()
+	state.copy(v.Type, result.Ptr, dataPointer(&v))
+	return result
+}
+
+// dataPointer returns a pointer to the data of v, including for values stored directly in v.Ptr.
+func dataPointer(v *Value) unsafe.Pointer {
+	if v.isPointer() {
+		return v.Ptr
+	}
+	return unsafe.Pointer(&v.Ptr)
+}
+
+// clone returns a copy of v (of type typ) in newly allocated memory.
+func (s *cloneState) clone(typ *RType, v Value) Value {
+	copied := unsafeNew(typ)
+	s.copy(typ, copied, dataPointer(&v))
+	return Value{Type: typ, Ptr: copied, Flag: pointerFlag | Flag(typ.Kind())}
+}
+
+// copy deep copies the value of type typ from src to dst, which points to zeroed memory.
+func (s *cloneState) copy(typ *RType, dst, src unsafe.Pointer) {
+	switch typ.Kind() {
+	case Ptr:
+		original := convPtr(src)
+		if original == nil {
+			return
+		}
+		key := cloneKey{ptr: original, typ: typ}
+		if copied, ok := s.copies[key]; ok {
+			loadConvPtr(dst, copied)
+			return
+		}
+		elem := typ.Deref()
+		copied := unsafeNew(elem)
+		// remember the copy before following the pointer, so cycles end here
+		s.copies[key] = copied
+		s.copy(elem, copied, original)
+		loadConvPtr(dst, copied)
+
+	case Map:
+		original := convPtr(src)
+		if original == nil {
+			return
+		}
+		key := cloneKey{ptr: original, typ: typ}
+		if copied, ok := s.copies[key]; ok {
+			loadConvPtr(dst, copied)
+			return
+		}
+		originalMap := ToMap(Value{Type: typ, Ptr: original, Flag: Flag(Map)})
+		copied := MakeMapWithSize(typ, originalMap.Len())
+		s.copies[key] = copied.Ptr
+		loadConvPtr(dst, copied.Ptr)
+		mapType := typ.ConvToMap()
+		iter := originalMap.Range()
+		for iter.Next() {
+			copied.SetMapIndex(s.clone(mapType.KeyType, iter.Key()), s.clone(mapType.ElemType, iter.Value()))
+		}
+
+	case Slice:
+		header := (*sliceHeader)(src)
+		if header.Data == nil {
+			return
+		}
+		elem := typ.ConvToSlice().ElemType
+		start := uintptr(header.Data)
+		end := start + uintptr(header.Cap)*elem.size
+		// the last array starting before the slice is the one which can hold it
+		i := sort.Search(len(s.arrays), func(i int) bool { return s.arrays[i].start > start })
+		if i > 0 {
+			if array := s.arrays[i-1]; array.elem == elem && start < array.end && end <= array.end {
+				*(*sliceHeader)(dst) = sliceHeader{Data: add(array.copied, start-array.start), Len: header.Len, Cap: header.Cap}
+				return
+			}
+		}
+		copied := unsafeNewArray(elem, header.Cap)
+		// remember the copy before copying the elements, so cycles end here
+		s.arrays = append(s.arrays, clonedArray{})
+		copy(s.arrays[i+1:], s.arrays[i:])
+		s.arrays[i] = clonedArray{start: start, end: end, elem: elem, copied: copied}
+		// the elements past the length are copied too, for the later slices which reach them
+		for j := 0; j < header.Cap; j++ {
+			s.copy(elem, arrayAt(copied, j, elem.size), arrayAt(header.Data, j, elem.size))
+		}
+		*(*sliceHeader)(dst) = sliceHeader{Data: copied, Len: header.Len, Cap: header.Cap}
+
+	case Array:
+		arrayType := typ.ConvToArray()
+		elem := arrayType.ElemType
+		if !elem.hasPointers() && elem.Kind() != Struct && elem.Kind() != Array {
+			// plain memory
+			typedmemmove(typ, dst, src)
+			return
+		}
+		for i := 0; i < int(arrayType.Len); i++ {
+			s.copy(elem, arrayAt(dst, i, elem.size), arrayAt(src, i, elem.size))
+		}
+
+	case Struct:
+		structType := typ.convToStruct()
+		for i := range structType.fields {
+			field := &structType.fields[i]
+			offset := structFieldOffset(field)
+			switch {
+			case field.name.isExported():
+				s.copy(field.Type, add(dst, offset), add(src, offset))
+			case isEmbedded(field) && (field.Type.Kind() == Struct || field.Type.Kind() == Ptr && field.Type.Deref().Kind() == Struct):
+				// the exported fields of an unexported embedded struct are promoted : follow them
+				s.copy(field.Type, add(dst, offset), add(src, offset))
+			case s.options&CloneUnexported != 0:
+				typedmemmove(field.Type, add(dst, offset), add(src, offset))
+			}
+		}
+
+	case Interface:
+		original := Value{Type: typ, Ptr: src, Flag: pointerFlag | Flag(Interface)}
+		if original.IsNil() {
+			return
+		}
+		elem := original.Iface()
+		Value{Type: typ, Ptr: dst, Flag: pointerFlag | addressableFlag | Flag(Interface)}.Set(s.clone(elem.Type, elem))
+
+	case Func:
+		if s.options&CloneShallowFuncs != 0 {
+			typedmemmove(typ, dst, src)
+		}
+
+	case Chan:
+		if s.options&CloneShallowChans != 0 {
+			typedmemmove(typ, dst, src)
+		}
+
+	default:
+		typedmemmove(typ, dst, src)
+	}
+}
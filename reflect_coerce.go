@@ -0,0 +1,212 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"math"
+	"strings"
+)
+
+// Coerce returns v converted to typ, like Convert, but by value rather than by Go's conversion rules :
+// strings are parsed into numbers and booleans (surrounding spaces are ignored, integers are decimal unless they have
+// the 0x, 0o or 0b base prefix of Go literals, so a leading zero is not octal, and accept exponents like "1e3" as long as
+// the result is whole), numbers and booleans are formatted into strings,
+// and numbers of every width are converted into each other.
+// Booleans become 1 and 0, and only 1 and 0 become booleans.
+// The result must hold the value exactly (except for the rounding of floats) : an integer which does not fit typ,
+// a negative value for an unsigned type or a float32 overflow is reported as ErrRange, a float with a fractional part
+// (or a NaN) into an integer as ErrNotConvertible and an unparsable string as ErrSyntax.
+// Kinds other than strings, integers, floats and booleans (and interfaces holding them) are reported as ErrNotConvertible.
+func Coerce(v Value, typ *RType) (Value, error) {
+	if !v.IsValid() || typ == nil {
+		return Value{}, ErrInvalidValue
+	}
+	if v.Kind() == Interface {
+		if v.IsNil() {
+			return Value{}, ErrInvalidValue
+		}
+		v = v.Iface()
+	}
+	f := v.ro()
+	switch v.Kind() {
+	case String:
+		return coerceString(f, v.String().Get(), typ)
+	case Bool:
+		if typ.Kind() == String {
+			if v.Bool().Get() {
+				return makeString(f, "true", typ), nil
+			}
+			return makeString(f, "false", typ), nil
+		}
+		if v.Bool().Get() {
+			return coerceInt(f, 1, typ)
+		}
+		return coerceInt(f, 0, typ)
+	case Int, Int8, Int16, Int32, Int64:
+		return coerceInt(f, v.Int().Get(), typ)
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		return coerceUint(f, v.Uint().Get(), typ)
+	case Float32, Float64:
+		return coerceFloat(f, v.Float().Get(), v.Type.Bits(), typ)
+	}
+	return Value{}, ErrNotConvertible
+}
+
+// coerceString parses s into a value of type typ.
+func coerceString(f Flag, s string, typ *RType) (Value, error) {
+	if typ.Kind() == String {
+		return makeString(f, s, typ), nil
+	}
+	s = strings.TrimSpace(s)
+	switch typ.Kind() {
+	case Bool:
+		b, err := ParseBool(s)
+		if err != nil {
+			return Value{}, ErrSyntax
+		}
+		return makeBool(f, b, typ), nil
+	case Int, Int8, Int16, Int32, Int64:
+		x, err := ParseInt(s, coerceBase(s), 64)
+		if err == nil {
+			return coerceInt(f, x, typ)
+		}
+		if isRangeError(err) {
+			return Value{}, ErrRange
+		}
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		x, err := ParseUint(s, coerceBase(s), 64)
+		if err == nil {
+			return coerceUint(f, x, typ)
+		}
+		if isRangeError(err) {
+			return Value{}, ErrRange
+		}
+		if strings.HasPrefix(s, "-") {
+			if _, err := ParseInt(s, coerceBase(s), 64); err == nil || isRangeError(err) {
+				return Value{}, ErrRange
+			}
+		}
+	case Float32, Float64:
+	default:
+		return Value{}, ErrNotConvertible
+	}
+	// floats, and integers written as floats ("1e3", "2.0")
+	x, err := ParseFloat(s, 64)
+	if err != nil {
+		if isRangeError(err) {
+			return Value{}, ErrRange
+		}
+		return Value{}, ErrSyntax
+	}
+	return coerceFloat(f, x, 64, typ)
+}
+
+// coerceBase returns the base coerceString parses the integer s in : 0 (the prefix tells) for the explicit 0x, 0o
+// and 0b prefixes, 10 otherwise, so that "010" is ten, like it is for floats.
+func coerceBase(s string) int {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	if len(s) > 2 && s[0] == '0' {
+		switch lowerASCII(s[1]) {
+		case 'x', 'o', 'b':
+			return 0
+		}
+	}
+	return 10
+}
+
+// coerceInt converts x into a value of type typ.
+func coerceInt(f Flag, x int64, typ *RType) (Value, error) {
+	switch typ.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		result := makeInt(f, uint64(x), typ)
+		if result.Int().Overflows(x) {
+			return Value{}, ErrRange
+		}
+		return result, nil
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		if x < 0 {
+			return Value{}, ErrRange
+		}
+		return coerceUint(f, uint64(x), typ)
+	case Float32, Float64:
+		return makeFloat(f, float64(x), typ), nil
+	case Bool:
+		if x != 0 && x != 1 {
+			return Value{}, ErrRange
+		}
+		return makeBool(f, x == 1, typ), nil
+	case String:
+		return makeString(f, string(appendInt(nil, x)), typ), nil
+	}
+	return Value{}, ErrNotConvertible
+}
+
+// coerceUint converts x into a value of type typ.
+func coerceUint(f Flag, x uint64, typ *RType) (Value, error) {
+	switch typ.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		if x > math.MaxInt64 {
+			return Value{}, ErrRange
+		}
+		return coerceInt(f, int64(x), typ)
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		result := makeInt(f, x, typ)
+		if result.Uint().Overflows(x) {
+			return Value{}, ErrRange
+		}
+		return result, nil
+	case Float32, Float64:
+		return makeFloat(f, float64(x), typ), nil
+	case Bool:
+		if x > 1 {
+			return Value{}, ErrRange
+		}
+		return makeBool(f, x == 1, typ), nil
+	case String:
+		return makeString(f, string(appendUint(nil, x, 10)), typ), nil
+	}
+	return Value{}, ErrNotConvertible
+}
+
+// coerceFloat converts x, which came from a float of the given bits, into a value of type typ.
+func coerceFloat(f Flag, x float64, bits int, typ *RType) (Value, error) {
+	switch typ.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		if x != math.Trunc(x) {
+			return Value{}, ErrNotConvertible
+		}
+		// -2^63 is exact as a float64, 2^63 is the first float64 above math.MaxInt64
+		if x < math.MinInt64 || x >= -math.MinInt64 {
+			return Value{}, ErrRange
+		}
+		return coerceInt(f, int64(x), typ)
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		if x != math.Trunc(x) {
+			return Value{}, ErrNotConvertible
+		}
+		if x < 0 || x >= 1<<64 {
+			return Value{}, ErrRange
+		}
+		return coerceUint(f, uint64(x), typ)
+	case Float32, Float64:
+		result := makeFloat(f, x, typ)
+		if result.Float().Overflows(x) {
+			return Value{}, ErrRange
+		}
+		return result, nil
+	case Bool:
+		if x != 0 && x != 1 {
+			return Value{}, ErrRange
+		}
+		return makeBool(f, x == 1, typ), nil
+	case String:
+		return makeString(f, string(appendFloat(nil, x, bits)), typ), nil
+	}
+	return Value{}, ErrNotConvertible
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"sort"
+)
+
+var (
+	diffOpNames = []string{
+		DiffChanged: "changed",
+		DiffAdded:   "added",
+		DiffRemoved: "removed",
+	}
+)
+
+// String returns the name of the operation.
+func (op DiffOp) String() string {
+	if int(op) < len(diffOpNames) {
+		return diffOpNames[op]
+	}
+	return "DiffOp" + I2A(int(op), -1)
+}
+
+// Diff compares a and b (usually two versions of the same model) and returns their differences, each one with a path.
+// It recurses through structs (exported fields only, promoted fields keep their promoted name), slices, arrays,
+// maps (in the order of their keys) and pointers. Paths follow the Go syntax : `Users[2].FirstName.String`, `Prices["eur"]`.
+// Slice elements, map entries and pointed values present on a single side are reported as added or removed.
+// Values of different types, basic values and structs without exported fields (like time.Time) are compared as a whole.
+// Pointers already compared are not followed again, so cyclic structures are safe.
+func Diff(a, b Value) []Change {
+	state := diffState{visited: make(map[visit]bool)}
+	state.diff(a, b, nil)
+	return state.changes
+}
+
+// MakeMapFromDifferences builds two maps from the result of Diff : the updates, which map each path to its new value,
+// and the changes, which map "From"+path to the old value and "To"+path to the new value. Missing values are stored as nil.
+func MakeMapFromDifferences(changes []Change) (map[string]interface{}, map[string]interface{}) {
+	updatesMap, changesMap := make(map[string]interface{}, len(changes)), make(map[string]interface{}, 2*len(changes))
+	for i := range changes {
+		oldValue, newValue := diffInterface(changes[i].Old), diffInterface(changes[i].New)
+		changesMap["From"+changes[i].Path] = oldValue
+		changesMap["To"+changes[i].Path] = newValue
+		updatesMap[changes[i].Path] = newValue
+	}
+	return updatesMap, changesMap
+}
+
+func diffInterface(v Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func (s *diffState) add(op DiffOp, path []byte, a, b Value) {
+	s.changes = append(s.changes, Change{Path: string(path), Op: op, Old: a, New: b})
+}
+
+// diff compares a and b, which are found at path. Children append to path : siblings are compared one after another,
+// so they can share the memory behind it.
+func (s *diffState) diff(a, b Value, path []byte) {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return
+	case !a.IsValid():
+		s.add(DiffAdded, path, Value{}, b)
+		return
+	case !b.IsValid():
+		s.add(DiffRemoved, path, a, Value{})
+		return
+	case a.Type != b.Type:
+		s.add(DiffChanged, path, a, b)
+		return
+	}
+
+	switch a.Kind() {
+	case Ptr, Interface:
+		aNil, bNil := a.IsNil(), b.IsNil()
+		switch {
+		case aNil && bNil:
+			return
+		case aNil:
+			s.add(DiffAdded, path, Value{}, b)
+			return
+		case bNil:
+			s.add(DiffRemoved, path, a, Value{})
+			return
+		}
+		if a.Kind() == Interface {
+			s.diff(a.Iface(), b.Iface(), path)
+			return
+		}
+		aPtr, bPtr := a.pointer(), b.pointer()
+		if aPtr == bPtr {
+			return
+		}
+		seen := visit{a1: aPtr, a2: bPtr, typ: a.Type}
+		if s.visited[seen] {
+			return
+		}
+		s.visited[seen] = true
+		s.diff(a.Deref(), b.Deref(), path)
+
+	case Struct:
+		if !hasExportedFields(a.Type) {
+			if !deepValueEqual(a, b, make(map[visit]bool), 0) {
+				s.add(DiffChanged, path, a, b)
+			}
+			return
+		}
+		structType := a.Type.convToStruct()
+		aStruct, bStruct := ToStruct(a), ToStruct(b)
+		for i := range structType.fields {
+			field := &structType.fields[i]
+			if isEmbedded(field) {
+				if embedded := field.Type; embedded.Kind() == Struct || embedded.Kind() == Ptr && embedded.Deref().Kind() == Struct {
+					// promoted fields are named as if they belong to the embedding struct
+					s.diff(aStruct.Field(i), bStruct.Field(i), path)
+					continue
+				}
+			}
+			if !field.name.isExported() {
+				continue
+			}
+			fieldPath := path
+			if len(fieldPath) > 0 {
+				fieldPath = append(fieldPath, '.')
+			}
+			s.diff(aStruct.Field(i), bStruct.Field(i), append(fieldPath, field.name.name()...))
+		}
+
+	case Slice, Array:
+		aSlice, bSlice := ToSlice(a), ToSlice(b)
+		aLen, bLen := aSlice.Len(), bSlice.Len()
+		if a.Kind() == Slice && aLen == bLen && a.Pointer() == b.Pointer() {
+			return
+		}
+		for i := 0; i < aLen || i < bLen; i++ {
+			elemPath := append(appendInt(append(path, '['), int64(i)), ']')
+			switch {
+			case i >= bLen:
+				s.add(DiffRemoved, elemPath, aSlice.Index(i), Value{})
+			case i >= aLen:
+				s.add(DiffAdded, elemPath, Value{}, bSlice.Index(i))
+			default:
+				s.diff(aSlice.Index(i), bSlice.Index(i), elemPath)
+			}
+		}
+
+	case Map:
+		aMap, bMap := ToMap(a), ToMap(b)
+		if a.Pointer() == b.Pointer() {
+			return
+		}
+		for _, entry := range sortedDiffKeys(aMap) {
+			entryPath := append(append(append(path, '['), entry.label...), ']')
+			if bValue := bMap.MapIndex(entry.key); bValue.IsValid() {
+				s.diff(aMap.MapIndex(entry.key), bValue, entryPath)
+			} else {
+				s.add(DiffRemoved, entryPath, aMap.MapIndex(entry.key), Value{})
+			}
+		}
+		for _, entry := range sortedDiffKeys(bMap) {
+			if !aMap.MapIndex(entry.key).IsValid() {
+				entryPath := append(append(append(path, '['), entry.label...), ']')
+				s.add(DiffAdded, entryPath, Value{}, bMap.MapIndex(entry.key))
+			}
+		}
+
+	default:
+		if !basicEqual(a, b) {
+			s.add(DiffChanged, path, a, b)
+		}
+	}
+}
+
+// basicEqual compares two values of the same type, which is neither a container nor a pointer.
+// Funcs, channels and unsafe pointers are equal when they point to the same thing.
+func basicEqual(a, b Value) bool {
+	switch a.Kind() {
+	case Bool:
+		return a.Bool().Get() == b.Bool().Get()
+	case Int, Int8, Int16, Int32, Int64:
+		return a.Int().Get() == b.Int().Get()
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		return a.Uint().Get() == b.Uint().Get()
+	case Float32, Float64:
+		return a.Float().Get() == b.Float().Get()
+	case Complex64, Complex128:
+		return a.Complex().Get() == b.Complex().Get()
+	case String:
+		return a.String().Get() == b.String().Get()
+	case Chan, Func, UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	}
+	return true
+}
+
+// sortedDiffKeys returns the keys of m with their printed form, sorted by it.
+func sortedDiffKeys(m MapValue) []diffKey {
+	keys := m.MapKeys()
+	result := make([]diffKey, len(keys))
+	for i := range keys {
+		result[i] = diffKey{label: appendKeyLabel(nil, keys[i]), key: keys[i]}
+	}
+	sort.Slice(result, func(i, j int) bool { return string(result[i].label) < string(result[j].label) })
+	return result
+}
+
+// appendKeyLabel appends the Go syntax like form of the map key k to buf : quoted strings, numbers, booleans,
+// pointers in hex and composite keys as the list of their parts.
+func appendKeyLabel(buf []byte, k Value) []byte {
+	switch k.Kind() {
+	case String:
+		return appendQuotedWith(buf, k.String().Get(), '"', false)
+	case Bool:
+		if k.Bool().Get() {
+			return append(buf, "true"...)
+		}
+		return append(buf, "false"...)
+	case Int, Int8, Int16, Int32, Int64:
+		return appendInt(buf, k.Int().Get())
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		return appendUint(buf, k.Uint().Get(), 10)
+	case Float32, Float64:
+		return appendFloat(buf, k.Float().Get(), k.Type.Bits())
+	case Complex64, Complex128:
+		c := k.Complex().Get()
+		buf = appendFloat(append(buf, '('), real(c), 64)
+		if imag(c) >= 0 {
+			buf = append(buf, '+')
+		}
+		return append(appendFloat(buf, imag(c), 64), 'i', ')')
+	case Interface:
+		if k.IsNil() {
+			return append(buf, "nil"...)
+		}
+		return appendKeyLabel(buf, k.Iface())
+	case Ptr, Chan, UnsafePointer:
+		return appendUint(append(buf, "0x"...), uint64(k.Pointer()), 16)
+	case Struct:
+		buf = append(buf, '{')
+		keyStruct := ToStruct(k)
+		for i, n := 0, keyStruct.NumField(); i < n; i++ {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = appendKeyLabel(buf, keyStruct.Field(i))
+		}
+		return append(buf, '}')
+	case Array:
+		buf = append(buf, '[')
+		keyArray := ToSlice(k)
+		for i, n := 0, keyArray.Len(); i < n; i++ {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = appendKeyLabel(buf, keyArray.Index(i))
+		}
+		return append(buf, ']')
+	}
+	return append(buf, k.Type.nomen()...)
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"encoding"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = TypeOf(time.Duration(0))
+	timeType            = TypeOf(time.Time{})
+	textUnmarshalerType = TypeOf((*encoding.TextUnmarshaler)(nil)).Deref()
+)
+
+// SetFromString sets the settable v from its textual representation s, the way a config file or a command line holds it.
+// Booleans and numbers are parsed by Coerce, complex numbers are written like "(1+2i)" and strings are taken as they are.
+// A time.Duration is parsed by time.ParseDuration and a time.Time as RFC3339. Types implementing encoding.TextUnmarshaler
+// (through a pointer receiver or not) unmarshal s themselves, and their error is returned as it is.
+// Nil pointers are allocated, then the pointed value is set.
+// Slices and arrays are comma separated lists ("a, b, c") and maps are comma separated key=value pairs ("a=1, b=2") :
+// items are trimmed and can be Go quoted (`"a,b"`) to hold commas, equal signs or spaces.
+// An empty interface receives s as a string.
+// A []byte receives the bytes of s.
+// Malformed input is reported as ErrSyntax, numbers out of range and too many array items as ErrRange,
+// kinds without a textual representation (funcs, channels, ...) as ErrNotConvertible and a v which is not settable
+// as ErrUnexported or ErrNotAssignable.
+func SetFromString(v Value, s string) error {
+	if !v.IsValid() {
+		return ErrInvalidValue
+	}
+	if !v.isExported() {
+		return ErrUnexported
+	}
+	if !v.CanSet() {
+		return ErrNotAssignable
+	}
+	return setFromString(v, s)
+}
+
+// setFromString sets the settable v from s.
+func setFromString(v Value, s string) error {
+	switch v.Type {
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return ErrSyntax
+		}
+		v.Int().Set(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return ErrSyntax
+		}
+		v.Set(ReflectOn(t))
+		return nil
+	}
+	if v.Kind() != Ptr && v.Kind() != Interface && v.Type.PtrTo().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case String:
+		v.String().Set(s)
+		return nil
+
+	case Bool, Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, UintPtr, Float32, Float64:
+		result, err := Coerce(ReflectOn(s), v.Type)
+		if err != nil {
+			return err
+		}
+		v.Set(result)
+		return nil
+
+	case Complex64, Complex128:
+		c, err := parseComplex(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		complexValue := v.Complex()
+		if complexValue.Overflows(c) {
+			return ErrRange
+		}
+		complexValue.Set(c)
+		return nil
+
+	case Ptr:
+		if !v.IsNil() {
+			return setFromString(v.Deref(), s)
+		}
+		elem := New(v.Type.Deref())
+		if err := setFromString(elem.Deref(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+
+	case Interface:
+		if v.Type.NoOfIfaceMethods() != 0 {
+			return ErrNotConvertible
+		}
+		v.Set(ReflectOn(s))
+		return nil
+
+	case Slice:
+		if v.Type.ConvToSlice().ElemType.Kind() == Uint8 {
+			// []byte holds the text itself
+			if bytes, err := TryConvert(ReflectOn(s), v.Type); err == nil {
+				v.Set(bytes)
+				return nil
+			}
+		}
+		items, err := splitItems(s, ',', -1)
+		if err != nil {
+			return err
+		}
+		slice := MakeSlice(v.Type, len(items), len(items))
+		for i := range items {
+			if err := setFromItem(slice.Index(i), items[i]); err != nil {
+				return err
+			}
+		}
+		v.Set(slice.Value)
+		return nil
+
+	case Array:
+		items, err := splitItems(s, ',', -1)
+		if err != nil {
+			return err
+		}
+		// the missing items are zero, and v is left untouched on failure
+		result := New(v.Type).Deref()
+		array := ToSlice(result)
+		if len(items) > array.Len() {
+			return ErrRange
+		}
+		for i := range items {
+			if err := setFromItem(array.Index(i), items[i]); err != nil {
+				return err
+			}
+		}
+		v.Set(result)
+		return nil
+
+	case Map:
+		entries, err := splitItems(s, ',', -1)
+		if err != nil {
+			return err
+		}
+		mapType := v.Type.ConvToMap()
+		result := MakeMapWithSize(v.Type, len(entries))
+		for _, entry := range entries {
+			pair, err := splitItems(entry, '=', 2)
+			if err != nil {
+				return err
+			}
+			if len(pair) != 2 {
+				return ErrSyntax
+			}
+			key, elem := New(mapType.KeyType).Deref(), New(mapType.ElemType).Deref()
+			if err := setFromItem(key, pair[0]); err != nil {
+				return err
+			}
+			if err := setFromItem(elem, pair[1]); err != nil {
+				return err
+			}
+			result.SetMapIndex(key, elem)
+		}
+		v.Set(result.Value)
+		return nil
+	}
+	return ErrNotConvertible
+}
+
+// setFromItem sets v from an item of a list : the item is trimmed and unquoted first.
+func setFromItem(v Value, item string) error {
+	item = strings.TrimSpace(item)
+	if len(item) > 0 && (item[0] == '"' || item[0] == '`') {
+		unquoted, err := Unquote(item)
+		if err != nil {
+			return err
+		}
+		item = unquoted
+	}
+	return setFromString(v, item)
+}
+
+// splitItems slices s around the separators which are not inside a Go quoted string, into n items at most
+// (all of them for a negative n). An empty (or blank) s has no items.
+func splitItems(s string, separator byte, n int) ([]string, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+	var items []string
+	start, quote := 0, byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == separator && (n < 0 || len(items) < n-1):
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, ErrSyntax
+	}
+	return append(items, s[start:]), nil
+}
+
+// parseComplex parses a complex number written like Go prints it : "(1+2i)", "1-2i", "2i" or "1.5".
+func parseComplex(s string) (complex128, error) {
+	if len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) == 0 {
+		return 0, ErrSyntax
+	}
+	if s[len(s)-1] != 'i' {
+		re, err := parseComplexPart(s)
+		return complex(re, 0), err
+	}
+	s = s[:len(s)-1]
+	// the imaginary part starts at the last sign which is not the first character nor an exponent sign
+	split := 0
+	for i := len(s) - 1; i > 0; i-- {
+		if (s[i] == '+' || s[i] == '-') && s[i-1] != 'e' && s[i-1] != 'E' {
+			split = i
+			break
+		}
+	}
+	var re, im float64
+	var err error
+	if split > 0 {
+		if re, err = parseComplexPart(s[:split]); err != nil {
+			return 0, err
+		}
+	}
+	switch imaginary := s[split:]; imaginary {
+	case "", "+":
+		im = 1
+	case "-":
+		im = -1
+	default:
+		if im, err = parseComplexPart(imaginary); err != nil {
+			return 0, err
+		}
+	}
+	return complex(re, im), nil
+}
+
+func parseComplexPart(s string) (float64, error) {
+	x, err := ParseFloat(s, 64)
+	if err != nil {
+		if isRangeError(err) {
+			return 0, ErrRange
+		}
+		return 0, ErrSyntax
+	}
+	return x, nil
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import "math"
+
+// Hash returns a hash of v, such that values which are DeepEqual have the same hash (for the same seed).
+// It walks v recursively : pointers and interfaces are followed, slices and arrays are hashed element by element
+// and maps independently of the order of their entries. Basic values, and structs and arrays free of pointers,
+// are hashed by the runtime hash function of their type, so the result is stable for the lifetime of the process,
+// but not across processes. Floats and complex numbers are hashed by their bits instead, all the NaNs alike,
+// so a value holding a NaN always has the same hash. A pointer from which a cycle is reachable is hashed by its type only,
+// so cyclic structures which unroll to the same values (like a node pointing to itself and a ring of equal nodes)
+// have the same hash.
+func Hash(v Value, seed uint64) uint64 {
+	state := hashState{}
+	return state.hash(v, seed)
+}
+
+// fnv1Uint64 folds the bytes of x into h, like fnv1 does.
+func fnv1Uint64(h, x uint64) uint64 {
+	for shift := uint(0); shift < 64; shift += 8 {
+		h = h*hashPrime64 ^ (x>>shift)&0xff
+	}
+	return h
+}
+
+// hashedByAlg reports whether the runtime hash function of t agrees with DeepEqual : it must not follow pointers.
+func hashedByAlg(t *RType) bool {
+	if t.alg == nil || t.alg.hash == nil {
+		return false
+	}
+	switch t.Kind() {
+	case Bool, Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, UintPtr, String, UnsafePointer:
+		return true
+	case Array, Struct:
+		return !t.hasPointers() && !hasFloats(t)
+	}
+	return false
+}
+
+// hasFloats reports whether t holds floats or complex numbers, which the runtime hashes randomly when they are NaN.
+func hasFloats(t *RType) bool {
+	switch t.Kind() {
+	case Float32, Float64, Complex64, Complex128:
+		return true
+	case Array:
+		return hasFloats(t.ConvToArray().ElemType)
+	case Struct:
+		structType := t.convToStruct()
+		for i := range structType.fields {
+			if hasFloats(structType.fields[i].Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// floatHashBits returns the bits of f to hash : all the NaNs have the same bits, and so have both zeros, which are equal.
+func floatHashBits(f float64) uint64 {
+	switch {
+	case f != f:
+		return 0x7FF8000000000001
+	case f == 0:
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+func (s *hashState) hash(v Value, h uint64) uint64 {
+	if !v.IsValid() {
+		return fnv1Uint64(h, 0)
+	}
+	typ := v.Type
+	h = fnv1Uint64(h, uint64(typ.hash))
+	if hashedByAlg(typ) {
+		return fnv1Uint64(h, uint64(typ.alg.hash(dataPointer(&v), uintptr(h))))
+	}
+
+	switch v.Kind() {
+	case Float32, Float64:
+		return fnv1Uint64(h, floatHashBits(v.Float().Get()))
+
+	case Complex64, Complex128:
+		c := v.Complex().Get()
+		return fnv1Uint64(fnv1Uint64(h, floatHashBits(real(c))), floatHashBits(imag(c)))
+
+	case Ptr:
+		if v.IsNil() {
+			return fnv1Uint64(h, 0)
+		}
+		// only pointers being walked stop the recursion : DeepEqual compares shared pointers every time it meets them
+		key := visit{a1: v.pointer(), typ: typ}
+		if s.walking[key] {
+			s.cycles++
+			return fnv1Uint64(h, hashCycleMarker)
+		}
+		if s.walking == nil {
+			s.walking = make(map[visit]bool)
+		}
+		s.walking[key] = true
+		cycles := s.cycles
+		elemHash := s.hash(v.Deref(), fnv1Uint64(h, 1))
+		delete(s.walking, key)
+		// the values met before looping depend on where the cycle was entered : they are left out
+		if s.cycles != cycles {
+			return fnv1Uint64(h, hashCycleMarker)
+		}
+		return elemHash
+
+	case Interface:
+		if v.IsNil() {
+			return fnv1Uint64(h, 0)
+		}
+		return s.hash(v.Iface(), fnv1Uint64(h, 1))
+
+	case Slice, Array:
+		if v.Kind() == Slice {
+			// nil and empty slices are not DeepEqual
+			if v.IsNil() {
+				return fnv1Uint64(h, 0)
+			}
+			h = fnv1Uint64(h, 1)
+		}
+		items := ToSlice(v)
+		n := items.Len()
+		h = fnv1Uint64(h, uint64(n))
+		for i := 0; i < n; i++ {
+			h = s.hash(items.Index(i), h)
+		}
+		return h
+
+	case Map:
+		if v.IsNil() {
+			return fnv1Uint64(h, 0)
+		}
+		entries := ToMap(v)
+		h = fnv1Uint64(fnv1Uint64(h, 1), uint64(entries.Len()))
+		// entries are hashed on their own, then added : the sum doesn't depend on the iteration order
+		var sum uint64
+		iter := entries.Range()
+		for iter.Next() {
+			sum += s.hash(iter.Value(), s.hash(iter.Key(), h))
+		}
+		return fnv1Uint64(h, sum)
+
+	case Struct:
+		fields := ToStruct(v)
+		for i, n := 0, fields.NumField(); i < n; i++ {
+			h = s.hash(fields.Field(i), h)
+		}
+		return h
+
+	case Func:
+		// non nil funcs are never DeepEqual, so only nil matters
+		if v.IsNil() {
+			return fnv1Uint64(h, 0)
+		}
+		return fnv1Uint64(h, 1)
+	}
+	return fnv1Uint64(h, uint64(v.Pointer()))
+}
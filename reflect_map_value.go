@@ -71,19 +71,111 @@ func (v MapValue) MapKeys() []Value {
 			// Someone deleted an entry from the map since we called maplen above. It's a data race, but nothing we can do about it.
 			break
 		}
-		if keyType.isDirectIface() {
-			// Copy result so future changes to the map won't change the underlying value.
-			keyValue := unsafeNew(keyType)
-			typedmemmove(keyType, keyValue, key)
-			result[i] = Value{Type: keyType, Ptr: keyValue, Flag: fl | pointerFlag}
-		} else {
-			result[i] = Value{Type: keyType, Ptr: convPtr(key), Flag: fl}
-		}
+		// Copy result so future changes to the map won't change the underlying value.
+		result[i] = copyVal(keyType, fl, key)
 		mapiternext(it)
 	}
 	return result[:i]
 }
 
+// Range returns a range iterator for a map, which does not allocate a slice of keys like MapKeys does.
+// Call Next to advance the iterator, and Key/Value to access each entry.
+// Next returns false when the iterator is exhausted.
+// Range follows the same iteration semantics as a range statement.
+//
+// Example:
+//
+//	iter := ToMap(ReflectOn(m)).Range()
+//	for iter.Next() {
+//		k := iter.Key()
+//		v := iter.Value()
+//		...
+//	}
+//
+func (v MapValue) Range() *MapIter {
+	return &MapIter{m: v}
+}
+
+// Reset modifies the iterator to iterate over v, so the same MapIter can be reused for another walk.
+func (it *MapIter) Reset(v MapValue) {
+	it.m = v
+	it.it = nil
+}
+
+// Next advances the map iterator and reports whether there is another entry.
+// It returns false when the iterator is exhausted; subsequent calls to Key, Value, or Next will panic.
+func (it *MapIter) Next() bool {
+	if it.it == nil {
+		it.it = mapiterinit(it.m.Type, it.m.pointer())
+	} else {
+		if mapiterkey(it.it) == nil {
+			panic("reflect.MapIter.Next called on exhausted iterator")
+		}
+		mapiternext(it.it)
+	}
+	return mapiterkey(it.it) != nil
+}
+
+// Key returns the key of the iterator's current map entry.
+func (it *MapIter) Key() Value {
+	key := it.current("Key")
+	keyType := it.m.Type.ConvToMap().KeyType
+	return copyVal(keyType, it.m.ro()|Flag(keyType.Kind()), key)
+}
+
+// Value returns the value of the iterator's current map entry.
+func (it *MapIter) Value() Value {
+	it.current("Value")
+	elemType := it.m.Type.ConvToMap().ElemType
+	return copyVal(elemType, it.m.ro()|Flag(elemType.Kind()), mapitervalue(it.it))
+}
+
+// KeyInto copies the key of the iterator's current map entry into dst, which must be settable.
+// Unlike Key, it does not allocate when the key type is directly assignable to dst's type.
+func (it *MapIter) KeyInto(dst Value) bool {
+	key := it.current("KeyInto")
+	return it.copyInto(dst, it.m.Type.ConvToMap().KeyType, key)
+}
+
+// ValueInto copies the value of the iterator's current map entry into dst, which must be settable.
+// Unlike Value, it does not allocate when the element type is directly assignable to dst's type.
+func (it *MapIter) ValueInto(dst Value) bool {
+	it.current("ValueInto")
+	return it.copyInto(dst, it.m.Type.ConvToMap().ElemType, mapitervalue(it.it))
+}
+
+// current returns the key pointer of the current entry, panicking if the iterator is not positioned on one.
+func (it *MapIter) current(method string) unsafe.Pointer {
+	if it.it == nil {
+		panic("reflect.MapIter." + method + " called before Next")
+	}
+	key := mapiterkey(it.it)
+	if key == nil {
+		panic("reflect.MapIter." + method + " called on exhausted iterator")
+	}
+	return key
+}
+
+func (it *MapIter) copyInto(dst Value, typ *RType, ptr unsafe.Pointer) bool {
+	if !dst.CanSet() || !it.m.isExported() {
+		if willPrintDebug {
+			panic("reflect.MapIter: destination is not settable")
+		}
+		return false
+	}
+	if typ.directlyAssignable(dst.Type) {
+		typedmemmove(dst.Type, dst.Ptr, ptr)
+		return true
+	}
+	if !typ.AssignableTo(dst.Type) {
+		if willPrintDebug {
+			panic("reflect.MapIter: value of type " + TypeToString(typ) + " is not assignable to type " + TypeToString(dst.Type))
+		}
+		return false
+	}
+	return dst.Set(copyVal(typ, Flag(typ.Kind()), ptr))
+}
+
 // SetMapIndex sets the value associated with key in the map v to val.
 // If val is the zero Value, SetMapIndex deletes the key from the map.
 // Otherwise if v holds a nil map, SetMapIndex will panic.
@@ -131,3 +223,27 @@ func (v MapValue) SetMapIndex(key, value Value) {
 	}
 	mapassign(v.Type, v.pointer(), keyPtr, elemPtr)
 }
+
+// TrySetMapIndex sets the value associated with key in the map v to value, like SetMapIndex,
+// but reports the reason of a failure instead of silently returning.
+// As in SetMapIndex, a zero value deletes the key from the map.
+func (v MapValue) TrySetMapIndex(key, value Value) error {
+	if !v.IsValid() || !key.IsValid() {
+		return ErrInvalidValue
+	}
+	if !v.isExported() || !key.isExported() || (value.IsValid() && !value.isExported()) {
+		return ErrUnexported
+	}
+	mapType := v.Type.ConvToMap()
+	if key.hasMethodFlag() || !key.Type.AssignableTo(mapType.KeyType) {
+		return ErrNotAssignable
+	}
+	if value.IsValid() && (value.hasMethodFlag() || !value.Type.AssignableTo(mapType.ElemType)) {
+		return ErrNotAssignable
+	}
+	if value.IsValid() && v.IsNil() {
+		return ErrNilPointer
+	}
+	v.SetMapIndex(key, value)
+	return nil
+}
@@ -9,16 +9,37 @@ package reflect
 import "unsafe"
 
 func newName(newName []byte) name {
+	return newFieldName(newName, nil, false)
+}
+
+// newFieldName builds a name carrying the exported bit and the optional tag data, as used by struct fields.
+func newFieldName(newName, tag []byte, exported bool) name {
 	if len(newName) > 1<<16-1 {
 		panic("reflect.x.error : name too long: " + string(newName))
 	}
+	if len(tag) > 1<<16-1 {
+		panic("reflect.x.error : tag too long: " + string(tag))
+	}
 	var bits byte
 	l := 1 + 2 + len(newName)
+	if exported {
+		bits |= 1 << 0
+	}
+	if len(tag) > 0 {
+		l += 2 + len(tag)
+		bits |= 1 << 1
+	}
 	b := make([]byte, l)
 	b[0] = bits
 	b[1] = uint8(len(newName) >> 8)
 	b[2] = uint8(len(newName))
 	copy(b[3:], newName)
+	if len(tag) > 0 {
+		tb := b[3+len(newName):]
+		tb[0] = uint8(len(tag) >> 8)
+		tb[1] = uint8(len(tag))
+		copy(tb[2:], tag)
+	}
 	return name{bytes: &b[0]}
 }
 
@@ -9,7 +9,14 @@ package reflect_test
 import (
 	"fmt"
 	. "github.com/badu/reflect"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
 	"testing"
+	"testing/quick"
+	"time"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -217,3 +224,2135 @@ func TestExplainRelation(t *testing.T) {
 func TestFastIToA(t *testing.T) {
 	t.Logf("%s", I2A(301293, -1))
 }
+
+func TestChanOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type T string
+	ct := ChanOf(BothDir, TypeOf(T("")))
+	if ct.Kind() != Chan || ct.ChanDir() != BothDir {
+		t.Fatalf("ChanOf returned %s with dir %d", ct, ct.ChanDir())
+	}
+	v := MakeChan(ct, 2)
+	runtime.GC()
+	v.Send(ReflectOn(T("hello")))
+	runtime.GC()
+	v.Send(ReflectOn(T("world")))
+	runtime.GC()
+
+	sv1, _ := v.Recv()
+	sv2, _ := v.Recv()
+	s1 := sv1.String().Get()
+	s2 := sv2.String().Get()
+	if s1 != "hello" || s2 != "world" {
+		t.Errorf("constructed chan: have %q, %q, want %q, %q", s1, s2, "hello", "world")
+	}
+
+	if s := ChanOf(RecvDir, TypeOf(T(""))).String(); s != "<-chan reflect_test.T" {
+		t.Errorf("ChanOf(RecvDir) = %q", s)
+	}
+	if s := ChanOf(BothDir, ChanOf(RecvDir, TypeOf(0))).String(); s != "chan (<-chan int)" {
+		t.Errorf("ChanOf(BothDir, <-chan int) = %q", s)
+	}
+
+	// check that type already in binary is found
+	type T1 int
+	checkSameType(t, Zero(ChanOf(BothDir, TypeOf(T1(1)))).Interface(), (chan T1)(nil))
+	checkSameType(t, Zero(ChanOf(SendDir, TypeOf(0))).Interface(), (chan<- int)(nil))
+
+	// a bidirectional channel is assignable to a directional one, but their types are not identical
+	bothDir, recvDir := TypeOf((chan int)(nil)), TypeOf((<-chan int)(nil))
+	if !bothDir.AssignableTo(recvDir) || !bothDir.ConvertibleTo(recvDir) {
+		t.Errorf("chan int should be assignable and convertible to <-chan int")
+	}
+	if recvDir.AssignableTo(bothDir) || recvDir.ConvertibleTo(bothDir) {
+		t.Errorf("<-chan int should not be assignable nor convertible to chan int")
+	}
+	if TypeOf([]chan int{}).ConvertibleTo(TypeOf([]<-chan int{})) {
+		t.Errorf("[]chan int should not be convertible to []<-chan int")
+	}
+	if TypeOf(map[string]chan int{}).AssignableTo(TypeOf(map[string]<-chan int{})) {
+		t.Errorf("map[string]chan int should not be assignable to map[string]<-chan int")
+	}
+}
+
+func TestChanValue(t *testing.T) {
+	c := make(chan int, 2)
+	cv := ToChan(ReflectOn(c))
+	if !cv.IsValid() {
+		t.Fatalf("ToChan returned invalid value")
+	}
+
+	if !cv.Send(ReflectOn(1)) {
+		t.Errorf("Send failed")
+	}
+	if !cv.TrySend(ReflectOn(2)) {
+		t.Errorf("TrySend on non full channel failed")
+	}
+	if cv.TrySend(ReflectOn(3)) {
+		t.Errorf("TrySend on full channel succeeded")
+	}
+	if cv.Len() != 2 || cv.Cap() != 2 {
+		t.Errorf("Len = %d, Cap = %d, want 2, 2", cv.Len(), cv.Cap())
+	}
+
+	v, ok := cv.Recv()
+	if !ok || v.Int().Get() != 1 {
+		t.Errorf("Recv = %s, %t, want 1, true", ValueToString(v), ok)
+	}
+	v, ok = cv.TryRecv()
+	if !ok || v.Int().Get() != 2 {
+		t.Errorf("TryRecv = %s, %t, want 2, true", ValueToString(v), ok)
+	}
+	v, ok = cv.TryRecv()
+	if v.IsValid() || ok {
+		t.Errorf("TryRecv on empty channel = %s, %t, want invalid, false", ValueToString(v), ok)
+	}
+
+	if !cv.Close() {
+		t.Errorf("Close failed")
+	}
+	v, ok = cv.Recv()
+	if !v.IsValid() || ok || v.Int().Get() != 0 {
+		t.Errorf("Recv on closed channel = %s, %t, want zero, false", ValueToString(v), ok)
+	}
+
+	// the element is stored indirectly
+	type big struct{ a, b, c int64 }
+	bc := make(chan big, 1)
+	bcv := ToChan(ReflectOn(bc))
+	bcv.Send(ReflectOn(big{1, 2, 3}))
+	bv, _ := bcv.Recv()
+	if got := bv.Interface().(big); got != (big{1, 2, 3}) {
+		t.Errorf("Recv of big value = %v", got)
+	}
+
+	// directional channel types
+	var recvOnly <-chan int = c
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Send on receive-only channel did not panic")
+			}
+		}()
+		ToChan(ReflectOn(recvOnly)).Send(ReflectOn(1))
+	}()
+
+	// the zero ChanValue
+	var zero ChanValue
+	if zero.Len() != 0 || zero.Cap() != 0 {
+		t.Errorf("zero ChanValue Len = %d, Cap = %d, want 0, 0", zero.Len(), zero.Cap())
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Recv on zero ChanValue did not panic")
+			}
+		}()
+		zero.Recv()
+	}()
+}
+
+func TestSelect(t *testing.T) {
+	c1 := make(chan int, 1)
+	c2 := make(chan string, 1)
+	c2 <- "ready"
+
+	// receive from the only ready channel, nil channels are ignored
+	cases := []SelectCase{
+		{Dir: SelectRecv, Chan: ReflectOn(c1)},
+		{Dir: SelectRecv, Chan: ReflectOn(c2)},
+		{Dir: SelectRecv, Chan: Value{}},
+	}
+	chosen, recv, recvOK := Select(cases)
+	if chosen != 1 || !recvOK || recv.String().Get() != "ready" {
+		t.Errorf("Select = %d, %s, %t, want 1, ready, true", chosen, ValueToString(recv), recvOK)
+	}
+
+	// default case when nothing is ready
+	cases = append(cases, SelectCase{Dir: SelectDefault})
+	chosen, recv, recvOK = Select(cases)
+	if chosen != 3 || recv.IsValid() || recvOK {
+		t.Errorf("Select with default = %d, %s, %t, want 3, invalid, false", chosen, ValueToString(recv), recvOK)
+	}
+
+	// send case
+	chosen, _, _ = Select([]SelectCase{
+		{Dir: SelectSend, Chan: ReflectOn(c1), Send: ReflectOn(42)},
+		{Dir: SelectRecv, Chan: ReflectOn(c2)},
+	})
+	if chosen != 0 || <-c1 != 42 {
+		t.Errorf("Select send chose %d", chosen)
+	}
+
+	// closed channel delivers zero value
+	close(c2)
+	chosen, recv, recvOK = Select([]SelectCase{{Dir: SelectRecv, Chan: ReflectOn(c2)}})
+	if chosen != 0 || recvOK || recv.String().Get() != "" {
+		t.Errorf("Select on closed channel = %d, %s, %t, want 0, empty, false", chosen, ValueToString(recv), recvOK)
+	}
+}
+
+func TestFuncOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type K string
+	type V float64
+
+	fn := func(args []Value) []Value {
+		if len(args) != 1 {
+			t.Errorf("args == %v, want exactly one arg", args)
+		} else if args[0].Type != TypeOf(K("")) {
+			t.Errorf("args[0] is type %v, want %v", args[0].Type, TypeOf(K("")))
+		} else if args[0].String().Get() != "gopher" {
+			t.Errorf("args[0] = %q, want %q", args[0].String().Get(), "gopher")
+		}
+		return []Value{ReflectOn(V(3.14))}
+	}
+	v := MakeFunc(FuncOf([]*RType{TypeOf(K(""))}, []*RType{TypeOf(V(0))}, false), fn)
+
+	outs, ok := v.Call([]Value{ReflectOn(K("gopher"))})
+	if !ok || len(outs) != 1 {
+		t.Fatalf("v.Call returned %v, want exactly one result", outs)
+	} else if outs[0].Type != TypeOf(V(0)) {
+		t.Fatalf("c.Call[0] is type %v, want %v", outs[0].Type, TypeOf(V(0)))
+	}
+	if f := outs[0].Float().Get(); f != 3.14 {
+		t.Errorf("constructed func returned %f, want %f", f, 3.14)
+	}
+
+	// the cache returns the same type for the same signature
+	if FuncOf([]*RType{TypeOf(K(""))}, []*RType{TypeOf(V(0))}, false) != v.Type {
+		t.Errorf("FuncOf did not return the cached type")
+	}
+
+	// check that types already in binary are found
+	type T1 int
+	testCases := []struct {
+		in, out  []*RType
+		variadic bool
+		want     interface{}
+	}{
+		{in: []*RType{TypeOf(T1(0))}, want: (func(T1))(nil)},
+		{in: []*RType{TypeOf(int(0))}, want: (func(int))(nil)},
+		{in: []*RType{SliceOf(TypeOf(int(0)))}, variadic: true, want: (func(...int))(nil)},
+		{in: []*RType{TypeOf(int(0))}, out: []*RType{TypeOf(false)}, want: (func(int) bool)(nil)},
+		{in: []*RType{TypeOf(int(0))}, out: []*RType{TypeOf(false), TypeOf("")}, want: (func(int) (bool, string))(nil)},
+	}
+	for _, tt := range testCases {
+		checkSameType(t, Zero(FuncOf(tt.in, tt.out, tt.variadic)).Interface(), tt.want)
+	}
+
+	if !FuncOf([]*RType{SliceOf(TypeOf(0))}, nil, true).IsVariadic() {
+		t.Errorf("FuncOf variadic type is not variadic")
+	}
+
+	// check that variadic requires last element be a slice.
+	FuncOf([]*RType{TypeOf(1), TypeOf(""), SliceOf(TypeOf(false))}, nil, true)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("FuncOf with non slice variadic parameter did not panic")
+			}
+		}()
+		FuncOf([]*RType{TypeOf(0), TypeOf(""), TypeOf(false)}, nil, true)
+	}()
+}
+
+func TestStructOf(t *testing.T) {
+	// check construction and use of type not in binary
+	fields := []StructField{
+		{
+			Name: "S",
+			Tag:  `json:"s"`,
+			Type: TypeOf(""),
+		},
+		{
+			Name: "X",
+			Tag:  `json:"x"`,
+			Type: TypeOf(byte(0)),
+		},
+		{
+			Name: "Y",
+			Type: TypeOf(uint64(0)),
+		},
+		{
+			Name: "Z",
+			Type: TypeOf([3]uint16{}),
+		},
+	}
+
+	st := StructOf(fields)
+	v := ToStruct(New(st).Deref())
+	runtime.GC()
+	v.Field(0).String().Set("foo")
+	runtime.GC()
+	v.Field(1).Uint().Set(1)
+	runtime.GC()
+
+	if got, want := st.String(), `struct { S string "json:\"s\""; X uint8 "json:\"x\""; Y uint64; Z [3]uint16 }`; got != want {
+		t.Errorf("constructed struct type string: got %q, want %q", got, want)
+	}
+	if s := v.Field(0).String().Get(); s != "foo" {
+		t.Errorf("constructed struct field S: got %q, want %q", s, "foo")
+	}
+	if x := v.Field(1).Uint().Get(); x != 1 {
+		t.Errorf("constructed struct field X: got %d, want 1", x)
+	}
+
+	// the layout matches what the compiler would produce
+	type layout struct {
+		S string `json:"s"`
+		X byte   `json:"x"`
+		Y uint64
+		Z [3]uint16
+	}
+	if st.Size() != unsafe.Sizeof(layout{}) || st.Align() != int(unsafe.Alignof(layout{})) {
+		t.Errorf("StructOf layout: size %d align %d, want size %d align %d", st.Size(), st.Align(), unsafe.Sizeof(layout{}), unsafe.Alignof(layout{}))
+	}
+	tags := map[string]string{}
+	st.Fields(func(Type *RType, name []byte, tag []byte, pack []byte, embedded, exported bool, offset uintptr, index int) {
+		tags[string(name)] = string(tag)
+		if !exported {
+			t.Errorf("field %s should be exported", name)
+		}
+	})
+	if tags["S"] != `json:"s"` || tags["X"] != `json:"x"` || tags["Y"] != "" {
+		t.Errorf("StructOf tags: got %v", tags)
+	}
+
+	// check the type is cached
+	if StructOf(fields) != st {
+		t.Errorf("StructOf did not return the cached type")
+	}
+
+	// check that types already in binary are found
+	type S1 struct{ A int }
+	checkSameType(t, Zero(StructOf([]StructField{{Name: "A", Type: TypeOf(0)}})).Interface(), struct{ A int }{})
+	checkSameType(t, Zero(StructOf([]StructField{{Name: "S1", Type: TypeOf(S1{}), Anonymous: true}})).Interface(), struct{ S1 }{})
+	checkSameType(t, Zero(StructOf(nil)).Interface(), struct{}{})
+
+	// embedded fields keep the embedded bit
+	embedded := StructOf([]StructField{{Name: "S1", Type: TypeOf(S1{}), Anonymous: true}, {Name: "B", Type: TypeOf(""), Tag: `json:"b"`}})
+	embedded.Fields(func(Type *RType, name []byte, tag []byte, pack []byte, isEmbedded, exported bool, offset uintptr, index int) {
+		if isEmbedded != (index == 0) {
+			t.Errorf("field %s: embedded = %v", name, isEmbedded)
+		}
+		if index == 1 && GetTagNamed(string(tag), "json") != "b" {
+			t.Errorf("field %s: tag %q", name, tag)
+		}
+	})
+
+	// check duplicate names and invalid names are rejected
+	shouldPanic := func(what string, fields []StructField) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("StructOf with %s did not panic", what)
+			}
+		}()
+		StructOf(fields)
+	}
+	shouldPanic("duplicate names", []StructField{{Name: "A", Type: TypeOf(0)}, {Name: "A", Type: TypeOf("")}})
+	shouldPanic("invalid name", []StructField{{Name: "1nvalid", Type: TypeOf(0)}})
+	shouldPanic("no type", []StructField{{Name: "A"}})
+	shouldPanic("unexported name without PkgPath", []StructField{{Name: "a", Type: TypeOf(0)}})
+}
+
+func TestStructOfGC(t *testing.T) {
+	type T *uintptr
+	tt := TypeOf(T(nil))
+	fields := []StructField{
+		{Name: "X", Type: tt},
+		{Name: "Y", Type: TypeOf(0)},
+		{Name: "Z", Type: tt},
+		{Name: "W", Type: TypeOf("")},
+	}
+	st := StructOf(fields)
+	if st.Size() != unsafe.Sizeof(struct {
+		X T
+		Y int
+		Z T
+		W string
+	}{}) {
+		t.Fatalf("StructOf size mismatch: %d", st.Size())
+	}
+
+	const n = 10
+	var x []interface{}
+	for i := 0; i < n; i++ {
+		v := ToStruct(New(st).Deref())
+		for j := 0; j < v.NumField(); j++ {
+			if v.Field(j).Kind() != Ptr {
+				continue
+			}
+			p := new(uintptr)
+			*p = uintptr(i*n + j)
+			v.Field(j).Set(Convert(ReflectOn(p), tt))
+		}
+		x = append(x, v.Interface())
+	}
+	runtime.GC()
+
+	for i, xi := range x {
+		v := ToStruct(ReflectOn(xi))
+		for j := 0; j < v.NumField(); j++ {
+			if v.Field(j).Kind() != Ptr {
+				continue
+			}
+			k := *v.Field(j).Interface().(T)
+			if k != uintptr(i*n+j) {
+				t.Errorf("lost x[%d].%d = %d, want %d", i, j, k, i*n+j)
+			}
+		}
+	}
+}
+
+func TestTryAPI(t *testing.T) {
+	type inner struct{ A int }
+	type outer struct {
+		*inner
+		B int
+		c string
+	}
+
+	o := outer{B: 1, c: "c"}
+	v := ToStruct(ReflectOnPtr(&o))
+
+	// TryField
+	if _, err := v.TryField(3); err != ErrOutOfRange {
+		t.Errorf("TryField(3): got %v, want %v", err, ErrOutOfRange)
+	}
+	if _, err := v.TryField(-1); err != ErrOutOfRange {
+		t.Errorf("TryField(-1): got %v, want %v", err, ErrOutOfRange)
+	}
+	b, err := v.TryField(1)
+	if err != nil {
+		t.Fatalf("TryField(1): unexpected error %v", err)
+	}
+
+	// TrySet
+	if err := b.TrySet(ReflectOn(2)); err != nil || o.B != 2 {
+		t.Errorf("TrySet: got %v, B = %d", err, o.B)
+	}
+	if err := b.TrySet(ReflectOn("two")); err != ErrNotAssignable {
+		t.Errorf("TrySet string to int: got %v, want %v", err, ErrNotAssignable)
+	}
+	if err := ReflectOn(1).TrySet(ReflectOn(2)); err != ErrNotAssignable {
+		t.Errorf("TrySet on non addressable: got %v, want %v", err, ErrNotAssignable)
+	}
+	if err := b.TrySet(Value{}); err != ErrInvalidValue {
+		t.Errorf("TrySet with zero Value: got %v, want %v", err, ErrInvalidValue)
+	}
+	c, _ := v.TryField(2)
+	if err := c.TrySet(ReflectOn("d")); err != ErrUnexported {
+		t.Errorf("TrySet on unexported field: got %v, want %v", err, ErrUnexported)
+	}
+	if err := b.TrySet(c); err != ErrUnexported {
+		t.Errorf("TrySet from unexported field: got %v, want %v", err, ErrUnexported)
+	}
+
+	// TryFieldByIndex through a nil embedded pointer
+	if _, err := v.TryFieldByIndex([]int{0, 0}); err != ErrNilPointer {
+		t.Errorf("TryFieldByIndex through nil pointer: got %v, want %v", err, ErrNilPointer)
+	}
+	o.inner = &inner{A: 5}
+	if a, err := v.TryFieldByIndex([]int{0, 0}); err != nil || a.Int().Get() != 5 {
+		t.Errorf("TryFieldByIndex: got %v, %v", ValueToString(a), err)
+	}
+	if _, err := v.TryFieldByIndex([]int{1, 0}); err != ErrOutOfRange {
+		t.Errorf("TryFieldByIndex into int: got %v, want %v", err, ErrOutOfRange)
+	}
+
+	// TryConvert
+	if cv, err := TryConvert(ReflectOn(3), TypeOf(float64(0))); err != nil || cv.Float().Get() != 3 {
+		t.Errorf("TryConvert int to float64: got %v, %v", ValueToString(cv), err)
+	}
+	if _, err := TryConvert(ReflectOn("3"), TypeOf(0)); err != ErrNotConvertible {
+		t.Errorf("TryConvert string to int: got %v, want %v", err, ErrNotConvertible)
+	}
+	if _, err := TryConvert(Value{}, TypeOf(0)); err != ErrInvalidValue {
+		t.Errorf("TryConvert zero Value: got %v, want %v", err, ErrInvalidValue)
+	}
+
+	// TrySetMapIndex
+	m := map[string]int{}
+	mv := ToMap(ReflectOn(m))
+	if err := mv.TrySetMapIndex(ReflectOn("a"), ReflectOn(1)); err != nil || m["a"] != 1 {
+		t.Errorf("TrySetMapIndex: got %v, m = %v", err, m)
+	}
+	if err := mv.TrySetMapIndex(ReflectOn(1), ReflectOn(1)); err != ErrNotAssignable {
+		t.Errorf("TrySetMapIndex bad key: got %v, want %v", err, ErrNotAssignable)
+	}
+	if err := mv.TrySetMapIndex(ReflectOn("a"), ReflectOn("b")); err != ErrNotAssignable {
+		t.Errorf("TrySetMapIndex bad value: got %v, want %v", err, ErrNotAssignable)
+	}
+	if err := mv.TrySetMapIndex(ReflectOn("a"), c); err != ErrUnexported {
+		t.Errorf("TrySetMapIndex unexported value: got %v, want %v", err, ErrUnexported)
+	}
+	if err := mv.TrySetMapIndex(ReflectOn("a"), Value{}); err != nil || len(m) != 0 {
+		t.Errorf("TrySetMapIndex delete: got %v, m = %v", err, m)
+	}
+	var nilMap map[string]int
+	if err := ToMap(ReflectOn(nilMap)).TrySetMapIndex(ReflectOn("a"), ReflectOn(1)); err != ErrNilPointer {
+		t.Errorf("TrySetMapIndex on nil map: got %v, want %v", err, ErrNilPointer)
+	}
+}
+
+func TestMapIter(t *testing.T) {
+	m := map[string]int{"one": 1, "two": 2, "three": 3}
+	iter := ToMap(ReflectOn(m)).Range()
+	got := map[string]int{}
+	for iter.Next() {
+		got[iter.Key().String().Get()] = int(iter.Value().Int().Get())
+	}
+	if len(got) != len(m) {
+		t.Fatalf("MapIter visited %d entries, want %d", len(got), len(m))
+	}
+	for k, v := range m {
+		if got[k] != v {
+			t.Errorf("MapIter: got %q=%d, want %d", k, got[k], v)
+		}
+	}
+
+	// calling Next on an exhausted iterator panics
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Next on exhausted iterator did not panic")
+			}
+		}()
+		iter.Next()
+	}()
+
+	// nil map has no entries
+	var nilMap map[string]int
+	if ToMap(ReflectOn(nilMap)).Range().Next() {
+		t.Errorf("Next on nil map returned true")
+	}
+
+	// reuse the iterator and preallocated storage
+	key, value := New(TypeOf("")).Deref(), New(TypeOf(0)).Deref()
+	iter.Reset(ToMap(ReflectOn(m)))
+	sum := 0
+	for iter.Next() {
+		if !iter.KeyInto(key) || !iter.ValueInto(value) {
+			t.Fatalf("KeyInto/ValueInto failed")
+		}
+		if m[key.String().Get()] != int(value.Int().Get()) {
+			t.Errorf("KeyInto/ValueInto: %q=%d", key.String().Get(), value.Int().Get())
+		}
+		sum += int(value.Int().Get())
+	}
+	if sum != 6 {
+		t.Errorf("KeyInto/ValueInto sum: got %d, want 6", sum)
+	}
+
+	// values are copies, independent from later map changes
+	big := map[[4]int]*int{{1, 2, 3, 4}: new(int)}
+	iter = ToMap(ReflectOn(big)).Range()
+	iter.Next()
+	k := iter.Key()
+	big[[4]int{5, 6, 7, 8}] = nil
+	if ValueToString(k) != "[4]int{1, 2, 3, 4}" {
+		t.Errorf("Key: got %s", ValueToString(k))
+	}
+
+	// destinations must be settable
+	iter.Reset(ToMap(ReflectOn(m)))
+	iter.Next()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("KeyInto non settable value did not panic")
+			}
+		}()
+		iter.KeyInto(ReflectOn(""))
+	}()
+
+	// interface destinations go through assignment
+	var iface interface{}
+	dst := ReflectOnPtr(&iface)
+	if !iter.ValueInto(dst) || iface == nil {
+		t.Errorf("ValueInto interface{}: got %v", iface)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		iter.Reset(ToMap(ReflectOn(m)))
+		for iter.Next() {
+			iter.KeyInto(key)
+			iter.ValueInto(value)
+		}
+	})
+	// mapiterinit allocates the runtime iterator itself, nothing else should
+	if allocs > 1 {
+		t.Errorf("walk with preallocated storage allocated %v times per run", allocs)
+	}
+}
+
+type Amount float64
+
+func (p Amount) Cents() int64                { return int64(p * 100) }
+func (p Amount) Format(prefix string) string { return prefix + I2A(int(p), -1) }
+func (p *Amount) Add(x float64)              { *p += Amount(x) }
+func (p Amount) unexported()                 {}
+
+type Amounts map[string]Amount
+
+func (l Amounts) Total() Amount {
+	var total Amount
+	for _, p := range l {
+		total += p
+	}
+	return total
+}
+
+func TestTypeMethods(t *testing.T) {
+	amountType := TypeOf(Amount(0))
+	if n := amountType.NumMethod(); n != 2 {
+		t.Fatalf("Amount.NumMethod: got %d, want 2", n)
+	}
+	if n := amountType.PtrTo().NumMethod(); n != 3 {
+		t.Fatalf("*Amount.NumMethod: got %d, want 3", n)
+	}
+
+	m := amountType.Method(0)
+	if m.Name != "Cents" || m.Index != 0 || m.PkgPath != "" {
+		t.Errorf("Amount.Method(0): got %q index %d pkg %q", m.Name, m.Index, m.PkgPath)
+	}
+	if got, want := m.Type.String(), "func(reflect_test.Amount) int64"; got != want {
+		t.Errorf("Amount.Cents type: got %q, want %q", got, want)
+	}
+	if got, want := m.MethodType.String(), "func() int64"; got != want {
+		t.Errorf("Amount.Cents method type: got %q, want %q", got, want)
+	}
+	res, ok := m.Func.Call([]Value{ReflectOn(Amount(1.5))})
+	if !ok || len(res) != 1 || res[0].Int().Get() != 150 {
+		t.Errorf("Amount.Cents call: got %v, %v", res, ok)
+	}
+
+	format, ok := amountType.MethodByName("Format")
+	if !ok {
+		t.Fatalf("Amount.MethodByName(Format) not found")
+	}
+	res, ok = format.Func.Call([]Value{ReflectOn(Amount(7)), ReflectOn("$")})
+	if !ok || len(res) != 1 || res[0].String().Get() != "$7" {
+		t.Errorf("Amount.Format call: got %v, %v", res, ok)
+	}
+	if _, ok := amountType.MethodByName("unexported"); ok {
+		t.Errorf("Amount.MethodByName found an unexported method")
+	}
+	if _, ok := amountType.MethodByName("Add"); ok {
+		t.Errorf("Amount.MethodByName found a pointer receiver method")
+	}
+
+	add, ok := amountType.PtrTo().MethodByName("Add")
+	if !ok {
+		t.Fatalf("*Amount.MethodByName(Add) not found")
+	}
+	p := Amount(1)
+	add.Func.Call([]Value{ReflectOn(&p), ReflectOn(2.5)})
+	if p != 3.5 {
+		t.Errorf("*Amount.Add call: got %v, want 3.5", p)
+	}
+
+	// named map type
+	total, ok := TypeOf(Amounts{}).MethodByName("Total")
+	if !ok {
+		t.Fatalf("Amounts.MethodByName(Total) not found")
+	}
+	res, ok = total.Func.Call([]Value{ReflectOn(Amounts{"a": 1, "b": 2})})
+	if !ok || len(res) != 1 || res[0].Float().Get() != 3 {
+		t.Errorf("Amounts.Total call: got %v, %v", res, ok)
+	}
+
+	// types without methods
+	if n := TypeOf(0).NumMethod(); n != 0 {
+		t.Errorf("int.NumMethod: got %d, want 0", n)
+	}
+	if _, ok := TypeOf(0).MethodByName("Any"); ok {
+		t.Errorf("int.MethodByName found a method")
+	}
+
+	// interface types report signatures without receiver
+	type stringer interface {
+		String() string
+	}
+	iface := TypeOf((*stringer)(nil)).Deref()
+	if iface.NumMethod() != 1 {
+		t.Fatalf("stringer.NumMethod: got %d, want 1", iface.NumMethod())
+	}
+	sm, ok := iface.MethodByName("String")
+	if !ok || sm.Type.String() != "func() string" || sm.Func.IsValid() {
+		t.Errorf("stringer.MethodByName(String): got %v %v", sm.Name, ok)
+	}
+}
+
+type pluginAPI interface {
+	Init(config map[string]string) error
+	Name() string
+	version() int
+}
+
+type goodPlugin struct{}
+
+func (goodPlugin) Init(map[string]string) error { return nil }
+func (goodPlugin) Name() string                 { return "good" }
+func (goodPlugin) version() int                 { return 1 }
+
+type badPlugin struct{}
+
+func (badPlugin) Init(map[string]int) error { return nil }
+func (badPlugin) version() int              { return 1 }
+
+func TestIfaceMethods(t *testing.T) {
+	iface := TypeOf((*pluginAPI)(nil)).Deref()
+
+	type seen struct {
+		name, pkgPath, signature string
+	}
+	var got []seen
+	iface.IfaceMethods(func(name []byte, pkgPath []byte, signature *RType, index int) {
+		if index != len(got) {
+			t.Errorf("IfaceMethods: index %d, want %d", index, len(got))
+		}
+		got = append(got, seen{string(name), string(pkgPath), signature.String()})
+	})
+	want := []seen{
+		{"Init", "", "func(map[string]string) error"},
+		{"Name", "", "func() string"},
+		{"version", "github.com/badu/reflect_test", "func() int"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("IfaceMethods: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("IfaceMethods[%d]: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if missing := TypeOf(goodPlugin{}).MissingMethods(iface); len(missing) != 0 {
+		t.Errorf("goodPlugin.MissingMethods: got %v", missing)
+	}
+	missing := TypeOf(badPlugin{}).MissingMethods(iface)
+	if len(missing) != 2 || missing[0].Name != "Init" || missing[1].Name != "Name" {
+		t.Fatalf("badPlugin.MissingMethods: got %v", missing)
+	}
+	if missing[0].Type.String() != "func(map[string]string) error" {
+		t.Errorf("badPlugin.MissingMethods: Init signature %s", missing[0].Type)
+	}
+	if _, ok := TypeOf(badPlugin{}).MethodByName("Init"); !ok {
+		t.Errorf("badPlugin.MethodByName(Init): not found")
+	}
+	if TypeOf(goodPlugin{}).Implements(iface) != (len(TypeOf(goodPlugin{}).MissingMethods(iface)) == 0) {
+		t.Errorf("Implements and MissingMethods disagree")
+	}
+
+	// an interface type checked against another interface type
+	type namer interface {
+		Name() string
+	}
+	if missing := iface.MissingMethods(TypeOf((*namer)(nil)).Deref()); len(missing) != 0 {
+		t.Errorf("pluginAPI.MissingMethods(namer): got %v", missing)
+	}
+	if missing := TypeOf((*namer)(nil)).Deref().MissingMethods(iface); len(missing) != 2 {
+		t.Errorf("namer.MissingMethods(pluginAPI): got %d methods, want 2", len(missing))
+	}
+}
+
+func TestPromotedFieldByName(t *testing.T) {
+	u := User{Username: "badu"}
+	u.Id = 42
+	v := ToStruct(ReflectOnPtr(&u))
+
+	id := v.FieldByName("Id")
+	if !id.IsValid() || id.Uint().Get() != 42 {
+		t.Fatalf("User.FieldByName(Id): got %s", ValueToString(id))
+	}
+	// promoted fields stay settable
+	id.Uint().Set(43)
+	if u.Id != 43 {
+		t.Errorf("User.FieldByName(Id) set: got %d, want 43", u.Id)
+	}
+	if f, ok := TypeOf(u).FieldByName("Id"); !ok || len(f.Index) != 2 || f.Index[0] != 0 || f.Index[1] != 0 || f.Type != TypeOf(uint64(0)) {
+		t.Errorf("User type FieldByName(Id): got %v %v", f.Index, ok)
+	} else if got := TypeOf(u).FieldByIndex(f.Index); got.Name != "Id" || got.Tag != f.Tag {
+		t.Errorf("User type FieldByIndex(%v): got %q", f.Index, got.Name)
+	}
+	if f, ok := TypeOf(u).FieldByName("Username"); !ok || len(f.Index) != 1 || f.Index[0] != 3 {
+		t.Errorf("User type FieldByName(Username): got %v %v", f.Index, ok)
+	}
+
+	// a direct field shadows the promoted one
+	p := Principal{Id: 7}
+	if id := ToStruct(ReflectOn(p)).FieldByName("Id"); id.Kind() != Int || id.Int().Get() != 7 {
+		t.Errorf("Principal.FieldByName(Id): got %s", ValueToString(id))
+	}
+
+	// ambiguous at equal depth, found at a shallower depth
+	type A struct{ X, Y int }
+	type B struct {
+		X int
+		Z int
+	}
+	type C struct {
+		Y int
+	}
+	type Ambiguous struct {
+		A
+		*B
+	}
+	type Shallow struct {
+		A
+		C
+		Y string
+	}
+	if _, ok := TypeOf(Ambiguous{}).FieldByName("X"); ok {
+		t.Errorf("Ambiguous.FieldByName(X) should not be found")
+	}
+	if f, ok := TypeOf(Ambiguous{}).FieldByName("Z"); !ok || len(f.Index) != 2 || f.Index[0] != 1 {
+		t.Errorf("Ambiguous.FieldByName(Z): got %v %v", f.Index, ok)
+	}
+	if f, ok := TypeOf(Shallow{}).FieldByName("Y"); !ok || f.Type.Kind() != String {
+		t.Errorf("Shallow.FieldByName(Y): got %v %v", f.Index, ok)
+	}
+	if _, ok := TypeOf(struct {
+		A
+		C
+	}{}).FieldByName("Y"); ok {
+		t.Errorf("FieldByName(Y) through A and C should be ambiguous")
+	}
+
+	// through embedded pointers
+	amb := Ambiguous{B: &B{Z: 9}}
+	if z := ToStruct(ReflectOn(amb)).FieldByName("Z"); z.Int().Get() != 9 {
+		t.Errorf("Ambiguous.FieldByName(Z): got %s", ValueToString(z))
+	}
+
+	// FieldByNameFunc
+	match := func(name []byte) bool { return len(name) > 0 && name[len(name)-1] == 'e' && name[0] == 'U' }
+	if f := v.FieldByNameFunc(match); f.String().Get() != "badu" {
+		t.Errorf("User.FieldByNameFunc: got %s", ValueToString(f))
+	}
+	if f := v.FieldByNameFunc(func([]byte) bool { return false }); f.IsValid() {
+		t.Errorf("User.FieldByNameFunc with no match: got %s", ValueToString(f))
+	}
+}
+
+func TestPlanOf(t *testing.T) {
+	userType := TypeOf(User{})
+	plan := PlanOf(userType)
+	if plan == nil || plan.Type != userType {
+		t.Fatalf("PlanOf(User): got %v", plan)
+	}
+	if PlanOf(userType.PtrTo()) != plan {
+		t.Errorf("PlanOf(*User) did not return the cached plan")
+	}
+
+	// concurrent callers share the same plan
+	type Fresh struct {
+		A int `json:"a"`
+	}
+	plans := make(chan *StructPlan, 8)
+	for i := 0; i < cap(plans); i++ {
+		go func() { plans <- PlanOf(TypeOf(Fresh{})) }()
+	}
+	first := <-plans
+	for i := 1; i < cap(plans); i++ {
+		if p := <-plans; p != first {
+			t.Errorf("concurrent PlanOf returned different plans")
+		}
+	}
+
+	// User : Entity (7 fields), FirstName, LastName, Username
+	if n := plan.NumField(); n != 11 {
+		t.Errorf("User plan: got %d fields, want 11", n)
+	}
+	id, ok := plan.Lookup([]byte("Id"))
+	if !ok || id.Depth != 1 || len(id.Index) != 2 || id.Index[0] != 0 || id.Index[1] != 0 || id.Indirect {
+		t.Fatalf("User plan Lookup(Id): got %+v %v", id, ok)
+	}
+	if value, ok := id.TagNamed([]byte("json")); !ok || string(value) != "id" {
+		t.Errorf("User plan Id json tag: got %q %v", value, ok)
+	}
+	if value, ok := id.TagNamed([]byte("orm")); !ok || string(value) != "primary_key" {
+		t.Errorf("User plan Id orm tag: got %q %v", value, ok)
+	}
+	if len(id.Tags) != 3 {
+		t.Errorf("User plan Id tags: got %d pairs, want 3", len(id.Tags))
+	}
+	if anon, ok := plan.Lookup([]byte("anonField")); !ok || anon.Exported || len(anon.PkgPath) == 0 {
+		t.Errorf("User plan Lookup(anonField): got %+v %v", anon, ok)
+	}
+	if _, ok := plan.Lookup([]byte("Missing")); ok {
+		t.Errorf("User plan Lookup(Missing) found a field")
+	}
+
+	u := User{Username: "badu"}
+	u.Id = 42
+	v := ToStruct(ReflectOnPtr(&u))
+	if got := id.Value(v); got.Uint().Get() != 42 {
+		t.Errorf("User plan Id value: got %s", ValueToString(got))
+	}
+	id.Value(v).Uint().Set(43)
+	if u.Id != 43 {
+		t.Errorf("User plan Id set: got %d", u.Id)
+	}
+	// unexported fields stay read only
+	anon, _ := plan.Lookup([]byte("anonField"))
+	if anon.Value(v).CanSet() {
+		t.Errorf("User plan anonField value is settable")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		field, _ := plan.Lookup([]byte("Username"))
+		field.Value(v)
+	})
+	if allocs != 0 {
+		t.Errorf("plan Lookup and Value allocated %v times", allocs)
+	}
+
+	// embedded pointers, ambiguous names and cycles
+	type Inner struct{ X, Y int }
+	type Node struct {
+		*Node
+		*Inner
+		Y string
+	}
+	nodePlan := PlanOf(TypeOf(Node{}))
+	x, ok := nodePlan.Lookup([]byte("X"))
+	if !ok || !x.Indirect {
+		t.Fatalf("Node plan Lookup(X): got %+v %v", x, ok)
+	}
+	if y, ok := nodePlan.Lookup([]byte("Y")); !ok || y.Depth != 0 || y.Type.Kind() != String {
+		t.Errorf("Node plan Lookup(Y): got %+v %v", y, ok)
+	}
+	node := Node{Inner: &Inner{X: 5}}
+	if got := x.Value(ToStruct(ReflectOn(node))); got.Int().Get() != 5 {
+		t.Errorf("Node plan X value: got %s", ValueToString(got))
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tag := []byte(`json:"username,omitempty,string" sql:"type:VARCHAR(55) NULL;NOT NULL; default : x" valid:"required~Name is required"`)
+	parser := ParseTag(tag)
+	var keys []string
+	for parser.Next() {
+		keys = append(keys, string(parser.Tag.Key))
+	}
+	if parser.Err() != nil {
+		t.Fatalf("ParseTag: unexpected error %v", parser.Err())
+	}
+	if len(keys) != 3 || keys[0] != "json" || keys[1] != "sql" || keys[2] != "valid" {
+		t.Fatalf("ParseTag keys: got %v", keys)
+	}
+
+	json, ok := parser.Lookup([]byte("json"))
+	if !ok {
+		t.Fatalf("ParseTag Lookup(json): not found")
+	}
+	if string(json.Name()) != "username" {
+		t.Errorf("json name: got %q", json.Name())
+	}
+	if !json.HasOption([]byte("omitempty")) || !json.HasOption([]byte("string")) || json.HasOption([]byte("username")) {
+		t.Errorf("json options: got %q", json.Value)
+	}
+	var options []string
+	for it := json.Options(); it.Next(); {
+		options = append(options, string(it.Option()))
+	}
+	if len(options) != 2 {
+		t.Errorf("json options: got %v", options)
+	}
+
+	sql, _ := parser.Lookup([]byte("sql"))
+	if value, ok := sql.Setting([]byte("TYPE")); !ok || string(value) != "VARCHAR(55) NULL" {
+		t.Errorf("sql type setting: got %q %v", value, ok)
+	}
+	if value, ok := sql.Setting([]byte("not null")); !ok || len(value) != 0 {
+		t.Errorf("sql NOT NULL setting: got %q %v", value, ok)
+	}
+	if value, ok := sql.Setting([]byte("default")); !ok || string(value) != "x" {
+		t.Errorf("sql default setting: got %q %v", value, ok)
+	}
+	if _, ok := sql.Setting([]byte("size")); ok {
+		t.Errorf("sql size setting found")
+	}
+	if _, ok := parser.Lookup([]byte("xml")); ok {
+		t.Errorf("Lookup(xml) found")
+	}
+
+	// names without options and dashes
+	dash, _ := ParseTag([]byte(`json:"-"`)).Lookup([]byte("json"))
+	if dashOptions := dash.Options(); string(dash.Name()) != "-" || dashOptions.Next() {
+		t.Errorf("json dash: got %q", dash.Value)
+	}
+
+	// escapes are kept raw
+	escaped := ParseTag([]byte(`desc:"a \"quoted\" word"`))
+	if !escaped.Next() || !escaped.Tag.HasEscapes() || string(escaped.Tag.Value) != `a \"quoted\" word` {
+		t.Errorf("escaped value: got %q", escaped.Tag.Value)
+	}
+
+	// syntax errors report their offset
+	for _, tt := range []struct {
+		tag    string
+		pairs  int
+		offset int
+	}{
+		{`json:"a" bad`, 1, 12},
+		{`json:a`, 0, 4},
+		{`json:"a`, 0, 5},
+		{`:"a"`, 0, 0},
+	} {
+		parser := ParseTag([]byte(tt.tag))
+		pairs := 0
+		for parser.Next() {
+			pairs++
+		}
+		if parser.Err() != ErrSyntax || pairs != tt.pairs || parser.Offset() != tt.offset {
+			t.Errorf("ParseTag(%q): got %d pairs, err %v at %d, want %d pairs, error at %d", tt.tag, pairs, parser.Err(), parser.Offset(), tt.pairs, tt.offset)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		parser := ParseTag(tag)
+		for parser.Next() {
+			parser.Tag.Name()
+			parser.Tag.HasOption([]byte("omitempty"))
+			parser.Tag.Setting([]byte("type"))
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("ParseTag allocated %v times", allocs)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	for _, model := range []interface{}{User{}, Customer{}, Invoice{}, Item{}, Principal{}, &Address{}} {
+		if errs := ValidateTags(TypeOf(model)); len(errs) != 0 {
+			t.Errorf("ValidateTags(%T): unexpected errors %v", model, errs)
+		}
+	}
+
+	// malformed tags are built at runtime, so vet doesn't complain about them.
+	// Other shadows Base.ID, so it's not a duplicate.
+	intType := TypeOf(0)
+	base := StructOf([]StructField{
+		{Name: "ID", Type: intType, Tag: `json:"id"`},
+		{Name: "Note", Type: intType, Tag: `json:"note" xml:"ns name"`},
+		{Name: "Spaced", Type: intType, Tag: `xml:" a"`},
+		{Name: "Again", Type: intType, Tag: `json:"id"`},
+	})
+	type Named struct {
+		ID int `json:"id"`
+	}
+	bad := StructOf([]StructField{
+		{Name: "Base", Type: base, Anonymous: true},
+		{Name: "Named", Type: TypeOf(Named{}), Tag: `json:"named"`, Anonymous: true},
+		{Name: "Other", Type: intType, Tag: `json:"id"`},
+		{Name: "Dup", Type: intType, Tag: `json:"dup" json:"again"`},
+		{Name: "Twin", Type: intType, Tag: `json:"dup,omitempty"`},
+		{Name: "Space", Type: intType, Tag: `json:"space,omitempty" sql:"x"xml:"y"`},
+		{Name: "Opts", Type: intType, Tag: `json:"opts, omitempty"`},
+		{Name: "Quote", Type: intType, Tag: `json:"open`},
+		{Name: "Key", Type: intType, Tag: `:"value"`},
+		{Name: "Pair", Type: intType, Tag: `json value`},
+		{Name: "Value", Type: intType, Tag: `json:value`},
+		{Name: "Bare", Type: intType, Tag: `json`},
+		{Name: "Trailing", Type: intType, Tag: `json:"a" xml`},
+		{Name: "Ignore", Type: intType, Tag: `json:"-"`},
+		{Name: "Skip", Type: intType, Tag: `json:"-"`},
+	})
+
+	type want struct {
+		path   string
+		offset int
+		reason string
+	}
+	wants := []want{
+		{"struct.Base.Spaced", 5, "suspicious space in struct tag value"},
+		{"struct.Dup", 11, "duplicate key \"json\""},
+		{"struct.Space", 30, "key:\"value\" pairs not separated by spaces"},
+		{"struct.Opts", 11, "suspicious space in struct tag value"},
+		{"struct.Quote", 5, "bad syntax for struct tag value"},
+		{"struct.Key", 0, "bad syntax for struct tag key"},
+		{"struct.Pair", 4, "bad syntax for struct tag pair"},
+		{"struct.Value", 4, "bad syntax for struct tag value"},
+		{"struct.Bare", 4, "bad syntax for struct tag pair"},
+		{"struct.Trailing", 12, "bad syntax for struct tag pair"},
+		{"struct.Base.Again", 6, "json tag \"id\" also at struct.Base.ID"},
+		{"struct.Twin", 6, "json tag \"dup\" also at struct.Dup"},
+	}
+	errs := ValidateTags(bad)
+	if len(errs) != len(wants) {
+		t.Fatalf("ValidateTags: got %d errors, want %d : %v", len(errs), len(wants), errs)
+	}
+	for _, w := range wants {
+		found := false
+		for _, err := range errs {
+			if err.Path == w.path && err.Offset == w.offset && err.Reason == w.reason {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("ValidateTags: missing %q at %d : %q, got %v", w.path, w.offset, w.reason, errs)
+		}
+	}
+	if msg := errs[0].Error(); msg == "" {
+		t.Errorf("TagError.Error returned an empty message")
+	}
+}
+
+func TestStructMapBinding(t *testing.T) {
+	type Line struct {
+		Sku      string `json:"sku"`
+		Quantity int64  `json:"qty"`
+	}
+	type Order struct {
+		Entity
+		Number   string   `json:"number"`
+		Note     string   `json:"note,omitempty"`
+		Secret   string   `json:"-"`
+		Total    float64  `json:"total"`
+		Lines    []Line   `json:"lines"`
+		Billing  *Address `json:"billing"`
+		Tags     []string `json:"tags,omitempty"`
+		internal int
+	}
+
+	order := Order{Number: "A-1", Secret: "hidden", Total: 9.5, Lines: []Line{{Sku: "p", Quantity: 2}}, internal: 1}
+	order.Id = 7
+	order.Billing = &Address{Street: NullString{String: "Main", Valid: true}}
+	m := StructToMap(ReflectOn(&order), "json")
+
+	if id, ok := m["id"].(uint64); !ok || id != 7 {
+		t.Errorf("StructToMap: embedded Entity id: got %#v", m["id"])
+	}
+	if number, ok := m["number"].(string); !ok || number != "A-1" {
+		t.Errorf("StructToMap: number: got %#v", m["number"])
+	}
+	for _, key := range []string{"note", "tags", "Secret", "-", "internal", "Entity"} {
+		if value, ok := m[key]; ok {
+			t.Errorf("StructToMap: unexpected key %q = %#v", key, value)
+		}
+	}
+	if typ := fmt.Sprintf("%T", m["createdAt"]); typ != "time.Time" {
+		t.Errorf("StructToMap: structs without exported fields are kept : got %s", typ)
+	}
+	lines, ok := m["lines"].([]interface{})
+	if !ok || len(lines) != 1 {
+		t.Fatalf("StructToMap: lines: got %#v", m["lines"])
+	}
+	if line, ok := lines[0].(map[string]interface{}); !ok || line["qty"] != int64(2) || line["sku"] != "p" {
+		t.Errorf("StructToMap: lines[0]: got %#v", lines[0])
+	}
+	billing, ok := m["billing"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("StructToMap: billing: got %#v", m["billing"])
+	}
+	if street, ok := billing["street"].(map[string]interface{}); !ok || street["openedOn"] != "Main" || len(street) != 1 {
+		t.Errorf("StructToMap: billing.street: got %#v", billing["street"])
+	}
+
+	// round trip
+	var back Order
+	if err := MapToStruct(m, ReflectOnPtr(&back), "json"); err != nil {
+		t.Fatalf("MapToStruct: unexpected error %v", err)
+	}
+	if back.Id != 7 || back.Number != "A-1" || back.Total != 9.5 || back.Secret != "" || back.internal != 0 {
+		t.Errorf("MapToStruct: got %+v", back)
+	}
+	if len(back.Lines) != 1 || back.Lines[0] != order.Lines[0] {
+		t.Errorf("MapToStruct: lines: got %+v", back.Lines)
+	}
+	if back.Billing == nil || back.Billing.Street.String != "Main" || back.Billing.Street.Valid {
+		t.Errorf("MapToStruct: billing: got %+v", back.Billing)
+	}
+
+	// nil entries reset the field
+	if err := MapToStruct(map[string]interface{}{"billing": nil}, ReflectOn(&back), "json"); err != nil || back.Billing != nil {
+		t.Errorf("MapToStruct: nil billing: got %v %+v", err, back.Billing)
+	}
+
+	// numeric widening
+	var line Line
+	if err := MapToStruct(map[string]interface{}{"qty": int32(5), "sku": "w"}, ReflectOnPtr(&line), "json"); err != nil || line.Quantity != 5 || line.Sku != "w" {
+		t.Errorf("MapToStruct: int32 into int64: got %v %+v", err, line)
+	}
+	if err := MapToStruct(map[string]interface{}{"total": float32(1.5)}, ReflectOnPtr(&back), "json"); err != nil || back.Total != 1.5 {
+		t.Errorf("MapToStruct: float32 into float64: got %v %v", err, back.Total)
+	}
+	for _, narrowing := range []interface{}{uint64(1), 1.0, "5"} {
+		err := MapToStruct(map[string]interface{}{"qty": narrowing}, ReflectOnPtr(&line), "json")
+		bindErr, ok := err.(*BindError)
+		if !ok || bindErr.Err != ErrNotConvertible || bindErr.Path != TypeOf(line).String()+".Quantity" {
+			t.Errorf("MapToStruct: %T into int64: got %v", narrowing, err)
+		}
+	}
+	if err := MapToStruct(map[string]interface{}{"total": int64(1)}, ReflectOnPtr(&back), "json"); err == nil {
+		t.Errorf("MapToStruct: int64 into float64 should not widen")
+	}
+
+	if err := MapToStruct(m, ReflectOn((*Order)(nil)), "json"); err != ErrNilPointer {
+		t.Errorf("MapToStruct: nil destination: got %v", err)
+	}
+	if err := MapToStruct(m, ReflectOn(back), "json"); err != ErrNotAssignable {
+		t.Errorf("MapToStruct: not settable destination: got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := Customer{
+		Name:      "acme",
+		Addresses: []*Address{{Street: NullString{String: "Main", Valid: true}}},
+		Users:     []*User{{Username: "a"}, {Username: "b"}, {Username: "c", FirstName: NullString{String: "Old", Valid: true}}},
+	}
+	after := before
+	after.Name = "acme inc"
+	after.Addresses = nil
+	after.Users = []*User{before.Users[0], before.Users[1], {Username: "c", FirstName: NullString{String: "New", Valid: true}}, {Username: "d"}}
+
+	changes := Diff(ReflectOn(before), ReflectOn(&after))
+	if len(changes) != 1 || changes[0].Op != DiffChanged || changes[0].Path != "" {
+		t.Errorf("Diff: different types : got %+v", changes)
+	}
+
+	changes = Diff(ReflectOn(before), ReflectOn(after))
+	want := []struct {
+		path string
+		op   DiffOp
+	}{
+		{"Name", DiffChanged},
+		{"Addresses[0]", DiffRemoved},
+		{"Users[2].FirstName.String", DiffChanged},
+		{"Users[3]", DiffAdded},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff: got %d changes, want %d : %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Path != w.path || changes[i].Op != w.op {
+			t.Errorf("Diff: change %d : got %s %s, want %s %s", i, changes[i].Op, changes[i].Path, w.op, w.path)
+		}
+	}
+	if changes[0].Old.String().Get() != "acme" || changes[0].New.String().Get() != "acme inc" {
+		t.Errorf("Diff: Name values : got %s -> %s", ValueToString(changes[0].Old), ValueToString(changes[0].New))
+	}
+	if changes[1].New.IsValid() || changes[3].Old.IsValid() {
+		t.Errorf("Diff: removed and added changes should miss a side")
+	}
+
+	updates, changed := MakeMapFromDifferences(changes)
+	if updates["Name"] != "acme inc" || changed["FromName"] != "acme" || changed["ToName"] != "acme inc" {
+		t.Errorf("MakeMapFromDifferences: Name : got %v %v %v", updates["Name"], changed["FromName"], changed["ToName"])
+	}
+	if updates["Users[2].FirstName.String"] != "New" || changed["FromUsers[2].FirstName.String"] != "Old" {
+		t.Errorf("MakeMapFromDifferences: nested path : got %v", updates)
+	}
+	if value, ok := updates["Addresses[0]"]; !ok || value != nil {
+		t.Errorf("MakeMapFromDifferences: removed value : got %v %v", value, ok)
+	}
+	if user, ok := updates["Users[3]"].(*User); !ok || user.Username != "d" {
+		t.Errorf("MakeMapFromDifferences: added value : got %#v", updates["Users[3]"])
+	}
+
+	// maps are walked in the order of their keys
+	changes = Diff(ReflectOn(map[string]int{"b": 2, "a": 1}), ReflectOn(map[string]int{"c": 4, "b": 3}))
+	if len(changes) != 3 ||
+		changes[0].Path != `["a"]` || changes[0].Op != DiffRemoved ||
+		changes[1].Path != `["b"]` || changes[1].Op != DiffChanged ||
+		changes[2].Path != `["c"]` || changes[2].Op != DiffAdded {
+		t.Errorf("Diff: maps : got %+v", changes)
+	}
+
+	// cycles are followed once
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	first, second := &Node{Name: "a"}, &Node{Name: "b"}
+	first.Next, second.Next = first, second
+	changes = Diff(ReflectOn(first), ReflectOn(second))
+	if len(changes) != 1 || changes[0].Path != "Name" {
+		t.Errorf("Diff: cycles : got %+v", changes)
+	}
+
+	if changes := Diff(ReflectOn(before), ReflectOn(before)); len(changes) != 0 {
+		t.Errorf("Diff: equal values : got %+v", changes)
+	}
+	if DiffAdded.String() != "added" {
+		t.Errorf("DiffOp.String : got %q", DiffAdded.String())
+	}
+}
+
+func TestClone(t *testing.T) {
+	type Node struct {
+		Host string
+		Next *Node
+	}
+	type Config struct {
+		Name     string
+		Limits   map[string]int
+		Hosts    []string
+		Primary  *Node
+		Backup   *Node
+		Extra    interface{}
+		Matrix   [2][]int
+		OnChange func()
+		Events   chan int
+		Self     *Config
+		secret   string
+	}
+	primary := &Node{Host: "a"}
+	primary.Next = primary
+	original := &Config{
+		Name:     "main",
+		Limits:   map[string]int{"conn": 10},
+		Hosts:    []string{"a", "b"},
+		Primary:  primary,
+		Backup:   primary,
+		Extra:    []int{1, 2},
+		Matrix:   [2][]int{{1}, {2}},
+		OnChange: func() {},
+		Events:   make(chan int),
+		secret:   "hidden",
+	}
+	original.Self = original
+
+	cloned := Clone(ReflectOn(original))
+	if !cloned.CanAddr() {
+		t.Errorf("Clone: result should be addressable")
+	}
+	copied, ok := cloned.Interface().(*Config)
+	if !ok || copied == original {
+		t.Fatalf("Clone: got %#v", cloned.Interface())
+	}
+	if copied.Name != "main" || copied.Limits["conn"] != 10 || len(copied.Hosts) != 2 || copied.Hosts[1] != "b" {
+		t.Errorf("Clone: got %+v", copied)
+	}
+	copied.Limits["conn"] = 20
+	copied.Hosts[0] = "z"
+	copied.Matrix[0][0] = 9
+	if original.Limits["conn"] != 10 || original.Hosts[0] != "a" || original.Matrix[0][0] != 1 {
+		t.Errorf("Clone: the copy shares memory with the original")
+	}
+	if copied.Primary == primary || copied.Primary != copied.Backup || copied.Primary.Next != copied.Primary {
+		t.Errorf("Clone: aliasing and cycles are not preserved : %p %p %p", copied.Primary, copied.Backup, copied.Primary.Next)
+	}
+	if copied.Self != copied {
+		t.Errorf("Clone: Self should point to the copy")
+	}
+	if extra, ok := copied.Extra.([]int); !ok || len(extra) != 2 || &extra[0] == &original.Extra.([]int)[0] {
+		t.Errorf("Clone: interface holding a slice : got %#v", copied.Extra)
+	}
+	if copied.OnChange != nil || copied.Events != nil || copied.secret != "" {
+		t.Errorf("Clone: funcs, channels and unexported fields should be left zero")
+	}
+
+	copied = Clone(ReflectOn(original), CloneShallowFuncs|CloneShallowChans, CloneUnexported).Interface().(*Config)
+	if copied.OnChange == nil || copied.Events != original.Events || copied.secret != "hidden" {
+		t.Errorf("Clone: with options : got %+v", copied)
+	}
+
+	// slices of the same backing array share its copy
+	backing := []int{1, 2, 3, 4}
+	lists := Clone(ReflectOn([][]int{backing[:2], backing, backing[1:]})).Interface().([][]int)
+	lists[1][1] = 20
+	if lists[0][1] != 20 || lists[2][0] != 20 || len(lists[1]) != 4 || lists[1][3] != 4 || backing[1] != 2 {
+		t.Errorf("Clone: slices of the same array should share its copy : got %v", lists)
+	}
+	// unless they reach outside of the first one met
+	lists = Clone(ReflectOn([][]int{backing[1:], backing})).Interface().([][]int)
+	lists[0][0] = 20
+	if lists[1][1] != 2 || lists[1][3] != 4 {
+		t.Errorf("Clone: a slice reaching outside of the first one should have its own copy : got %v", lists)
+	}
+
+	// values which are not pointers
+	value := Clone(ReflectOn(map[string][]int{"a": {1}}))
+	if m, ok := value.Interface().(map[string][]int); !ok || len(m["a"]) != 1 || m["a"][0] != 1 {
+		t.Errorf("Clone: map : got %#v", value.Interface())
+	}
+	if Clone(Value{}).IsValid() {
+		t.Errorf("Clone: invalid value should give an invalid value")
+	}
+}
+
+func TestDeepEqualWith(t *testing.T) {
+	type Version struct {
+		Major, Minor int
+	}
+	type Reading struct {
+		Sensor  string
+		Value   float64
+		Version Version
+		Tags    []string
+		Meta    map[string]int
+		Seen    int `volatile:"true"`
+	}
+	zero := 0.0
+	nan := zero / zero
+
+	if ok, path := DeepEqualWith(Reading{Sensor: "a"}, Reading{Sensor: "a"}); !ok || path != "" {
+		t.Errorf("DeepEqualWith: equal values : got %v %q", ok, path)
+	}
+	if ok, path := DeepEqualWith(Reading{}, &Reading{}); ok || path != "" {
+		t.Errorf("DeepEqualWith: different types : got %v %q", ok, path)
+	}
+
+	before := Customer{Users: []*User{{Username: "a"}, {Username: "b"}, {FirstName: NullString{String: "Old"}}}}
+	after := Customer{Users: []*User{{Username: "a"}, {Username: "b"}, {FirstName: NullString{String: "New"}}}}
+	if ok, path := DeepEqualWith(before, after); ok || path != "Users[2].FirstName.String" {
+		t.Errorf("DeepEqualWith: nested mismatch : got %v %q", ok, path)
+	}
+	if ok, path := DeepEqualWith(before, after, IgnoreFields("FirstName")); !ok {
+		t.Errorf("DeepEqualWith: IgnoreFields : got %v %q", ok, path)
+	}
+	if DeepEqual(before, after) {
+		t.Errorf("DeepEqual: should not be altered by DeepEqualWith")
+	}
+
+	if ok, path := DeepEqualWith(Reading{Seen: 1}, Reading{Seen: 2}, IgnoreTagged("volatile", "")); !ok {
+		t.Errorf("DeepEqualWith: IgnoreTagged any value : got %v %q", ok, path)
+	}
+	if ok, path := DeepEqualWith(Reading{Seen: 1}, Reading{Seen: 2}, IgnoreTagged("volatile", "false")); ok || path != "Seen" {
+		t.Errorf("DeepEqualWith: IgnoreTagged other value : got %v %q", ok, path)
+	}
+
+	if ok, _ := DeepEqualWith(Reading{Value: 1}, Reading{Value: 1.005}, FloatEpsilon(0.01)); !ok {
+		t.Errorf("DeepEqualWith: FloatEpsilon should accept 1 and 1.005")
+	}
+	if ok, path := DeepEqualWith(Reading{Value: 1}, Reading{Value: 1.1}, FloatEpsilon(0.01)); ok || path != "Value" {
+		t.Errorf("DeepEqualWith: FloatEpsilon : got %v %q", ok, path)
+	}
+	if ok, _ := DeepEqualWith(Reading{Value: nan}, Reading{Value: nan}); ok {
+		t.Errorf("DeepEqualWith: NaN should differ from NaN by default")
+	}
+	if ok, _ := DeepEqualWith(Reading{Value: nan}, Reading{Value: nan}, NaNEqual()); !ok {
+		t.Errorf("DeepEqualWith: NaNEqual")
+	}
+
+	empty := Reading{Tags: []string{}, Meta: map[string]int{}}
+	if ok, path := DeepEqualWith(Reading{}, empty); ok || path != "Tags" {
+		t.Errorf("DeepEqualWith: nil and empty slices : got %v %q", ok, path)
+	}
+	if ok, path := DeepEqualWith(Reading{}, empty, NilEqualsEmpty()); !ok {
+		t.Errorf("DeepEqualWith: NilEqualsEmpty : got %v %q", ok, path)
+	}
+
+	majorOnly := CompareWith(TypeOf(Version{}), func(x, y interface{}) bool {
+		return x.(Version).Major == y.(Version).Major
+	})
+	if ok, path := DeepEqualWith(Reading{Version: Version{1, 2}}, Reading{Version: Version{1, 3}}, majorOnly); !ok {
+		t.Errorf("DeepEqualWith: CompareWith : got %v %q", ok, path)
+	}
+	if ok, path := DeepEqualWith(Reading{Version: Version{1, 2}}, Reading{Version: Version{2, 2}}, majorOnly); ok || path != "Version" {
+		t.Errorf("DeepEqualWith: CompareWith mismatch : got %v %q", ok, path)
+	}
+
+	if ok, path := DeepEqualWith(Reading{Meta: map[string]int{"a": 1}}, Reading{Meta: map[string]int{"a": 2}}); ok || path != `Meta["a"]` {
+		t.Errorf("DeepEqualWith: map mismatch : got %v %q", ok, path)
+	}
+
+	// the path to a mismatch is only built when it is reported
+	var first, equal, other interface{} = []Version{{1, 2}}, []Version{{1, 2}}, []Version{{1, 3}}
+	equalAllocs := testing.AllocsPerRun(10, func() { DeepEqual(first, equal) })
+	if allocs := testing.AllocsPerRun(10, func() { DeepEqual(first, other) }); allocs != equalAllocs {
+		t.Errorf("DeepEqual: a mismatch allocates %v times, an equal value %v times", allocs, equalAllocs)
+	}
+}
+
+func TestHash(t *testing.T) {
+	customer := func(name string) *Customer {
+		return &Customer{
+			Name:  "acme",
+			Users: []*User{{Username: "a"}, {Username: name, FirstName: NullString{String: "First", Valid: true}}},
+		}
+	}
+	first, second := customer("b"), customer("b")
+	if Hash(ReflectOn(first), 0) != Hash(ReflectOn(second), 0) {
+		t.Errorf("Hash: DeepEqual values should have the same hash")
+	}
+	if Hash(ReflectOn(first), 0) == Hash(ReflectOn(customer("c")), 0) {
+		t.Errorf("Hash: a nested change should change the hash")
+	}
+	if Hash(ReflectOn(first), 0) == Hash(ReflectOn(first), 1) {
+		t.Errorf("Hash: the seed should change the hash")
+	}
+
+	ordered, reversed := make(map[string][]int), make(map[string][]int)
+	for i := 0; i < 50; i++ {
+		ordered[I2A(i, -1)] = []int{i}
+		reversed[I2A(49-i, -1)] = []int{49 - i}
+	}
+	if Hash(ReflectOn(ordered), 7) != Hash(ReflectOn(reversed), 7) {
+		t.Errorf("Hash: maps should hash independently of their order")
+	}
+	reversed["0"] = []int{1}
+	if Hash(ReflectOn(ordered), 7) == Hash(ReflectOn(reversed), 7) {
+		t.Errorf("Hash: a changed map entry should change the hash")
+	}
+
+	if Hash(ReflectOn([]int(nil)), 0) == Hash(ReflectOn([]int{}), 0) {
+		t.Errorf("Hash: nil and empty slices are not DeepEqual")
+	}
+	if Hash(ReflectOn(int32(1)), 0) == Hash(ReflectOn(int64(1)), 0) {
+		t.Errorf("Hash: values of different types should differ")
+	}
+	var x, y interface{} = 1.5, 1.5
+	if Hash(ReflectOn(&x), 0) != Hash(ReflectOn(&y), 0) {
+		t.Errorf("Hash: interfaces holding equal values")
+	}
+
+	// shared pointers are hashed like distinct equal ones, as DeepEqual compares them
+	type Pair struct {
+		Left, Right *Address
+	}
+	shared := &Address{Street: NullString{String: "Main"}}
+	sharing, distinct := Pair{shared, shared}, Pair{&Address{Street: NullString{String: "Main"}}, &Address{Street: NullString{String: "Main"}}}
+	if !DeepEqual(sharing, distinct) || Hash(ReflectOn(sharing), 0) != Hash(ReflectOn(distinct), 0) {
+		t.Errorf("Hash: shared and distinct pointers")
+	}
+
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	ring1, ring2 := &Node{Name: "a"}, &Node{Name: "a"}
+	ring1.Next, ring2.Next = ring1, ring2
+	if Hash(ReflectOn(ring1), 0) != Hash(ReflectOn(ring2), 0) {
+		t.Errorf("Hash: equal cycles should have the same hash")
+	}
+	// a node pointing to itself and a ring of two equal nodes are DeepEqual
+	pair := &Node{Name: "a", Next: &Node{Name: "a"}}
+	pair.Next.Next = pair
+	if !DeepEqual(ring1, pair) {
+		t.Fatalf("Hash: a self loop and a ring of equal nodes should be DeepEqual")
+	}
+	if Hash(ReflectOn(ring1), 0) != Hash(ReflectOn(pair), 0) {
+		t.Errorf("Hash: a self loop and a ring of equal nodes should have the same hash")
+	}
+	if Hash(ReflectOn(Node{Name: "a"}), 0) == Hash(ReflectOn(Node{Name: "b"}), 0) {
+		t.Errorf("Hash: acyclic values should still be hashed by their content")
+	}
+
+	// the runtime hashes NaNs randomly
+	type Sample struct {
+		At    int
+		Value float64
+	}
+	nan := math.NaN()
+	values, sample := []float64{1, nan}, Sample{At: 1, Value: nan}
+	if Hash(ReflectOn(values), 3) != Hash(ReflectOn(values), 3) || Hash(ReflectOn(sample), 3) != Hash(ReflectOn(sample), 3) {
+		t.Errorf("Hash: a value holding a NaN should always have the same hash")
+	}
+	if Hash(ReflectOn(complex(nan, 1)), 3) != Hash(ReflectOn(complex(-nan, 1)), 3) {
+		t.Errorf("Hash: NaNs should have the same hash")
+	}
+	if Hash(ReflectOn(0.0), 3) != Hash(ReflectOn(math.Copysign(0, -1)), 3) {
+		t.Errorf("Hash: both zeros should have the same hash")
+	}
+	if Hash(ReflectOn(Sample{Value: 1}), 3) == Hash(ReflectOn(Sample{Value: 2}), 3) {
+		t.Errorf("Hash: different floats should change the hash")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	type Tree struct {
+		Name     string
+		Children []*Tree
+		Attrs    map[string]int
+		Parent   *Tree
+	}
+	root := &Tree{Name: "root", Attrs: map[string]int{"b": 2, "a": 1}}
+	root.Children = []*Tree{{Name: "kid", Parent: root}}
+
+	var entered []string
+	leaves := 0
+	record := func(node *WalkNode) string {
+		result := string(node.Path) + ":" + StringKind(node.Kind) + ":" + I2A(node.Depth, -1)
+		if node.Cycle {
+			result += ":cycle"
+		}
+		return result
+	}
+	completed := Walk(ReflectOn(root), WalkFuncs{
+		OnEnter: func(node *WalkNode) WalkAction {
+			entered = append(entered, record(node))
+			return WalkContinue
+		},
+		OnLeave: func(node *WalkNode) WalkAction {
+			leaves++
+			return WalkContinue
+		},
+	})
+	want := []string{
+		":ptr:0",
+		":struct:1",
+		"Name:string:2",
+		"Children:slice:2",
+		"Children[0]:ptr:3",
+		"Children[0]:struct:4",
+		"Children[0].Name:string:5",
+		"Children[0].Children:slice:5",
+		"Children[0].Attrs:map:5",
+		"Children[0].Parent:ptr:5:cycle",
+		"Attrs:map:2",
+		`Attrs["a"]:int:3`,
+		`Attrs["b"]:int:3`,
+		"Parent:ptr:2",
+	}
+	if !completed || len(entered) != len(want) || leaves != len(want) {
+		t.Fatalf("Walk: completed %v, %d leaves, entered %q", completed, leaves, entered)
+	}
+	for i := range want {
+		if entered[i] != want[i] {
+			t.Errorf("Walk: node %d : got %q, want %q", i, entered[i], want[i])
+		}
+	}
+
+	// control flow
+	entered = entered[:0]
+	completed = Walk(ReflectOn(root), WalkFuncs{
+		OnEnter: func(node *WalkNode) WalkAction {
+			entered = append(entered, string(node.Path))
+			switch string(node.Path) {
+			case "Children":
+				return SkipChildren
+			case `Attrs["a"]`:
+				return Stop
+			}
+			return WalkContinue
+		},
+		OnLeave: func(node *WalkNode) WalkAction {
+			if string(node.Path) == `Attrs["a"]` {
+				t.Errorf("Walk: Leave called on the node which stopped the walk")
+			}
+			return WalkContinue
+		},
+	})
+	if completed || len(entered) != 6 || entered[3] != "Children" || entered[4] != "Attrs" {
+		t.Errorf("Walk: SkipChildren and Stop : completed %v, entered %q", completed, entered)
+	}
+
+	// struct field nodes carry their name, tag and index
+	var tags []string
+	Walk(ReflectOn(User{}), WalkFuncs{OnEnter: func(node *WalkNode) WalkAction {
+		if node.Depth == 1 && node.Name != nil {
+			tags = append(tags, string(node.Name)+"@"+I2A(node.Index, -1)+"="+string(node.Tag))
+		}
+		return SkipChildren
+	}})
+	if len(tags) != 0 {
+		t.Errorf("Walk: SkipChildren on the root should not visit fields : got %q", tags)
+	}
+	Walk(ReflectOn(User{}), WalkFuncs{OnEnter: func(node *WalkNode) WalkAction {
+		if node.Depth == 1 {
+			tags = append(tags, string(node.Name)+"@"+I2A(node.Index, -1)+"="+string(node.Tag))
+			return SkipChildren
+		}
+		return WalkContinue
+	}})
+	if len(tags) != 4 || tags[3] != `Username@3=json:"username" sql:"VARCHAR(55);NOT NULL" valid:"required~Name is required,length(3|50)~Username is too short"` {
+		t.Errorf("Walk: field nodes : got %q", tags)
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	negativeZero := 0.0
+	negativeZero = -negativeZero
+	var nilError error
+	var nilFunc func()
+	var nilChan chan int
+	tests := []struct {
+		value interface{}
+		zero  bool
+	}{
+		{0, true},
+		{int8(1), false},
+		{0.0, true},
+		{negativeZero, false},
+		{complex64(0), true},
+		{false, true},
+		{true, false},
+		{"", true},
+		{"a", false},
+		{[]int(nil), true},
+		{[]int{}, false},
+		{map[string]int(nil), true},
+		{map[string]int{}, false},
+		{(*int)(nil), true},
+		{new(int), false},
+		{nilFunc, true},
+		{nilChan, true},
+		{unsafe.Pointer(nil), true},
+		{[3]int{}, true},
+		{[3]int{0, 0, 1}, false},
+		{[2]string{}, true},
+		{[2]string{"", "a"}, false},
+		{struct {
+			A int
+			B string
+		}{}, true},
+		{struct {
+			A int
+			b string
+		}{b: "x"}, false},
+		{struct{}{}, true},
+		{Invoice{}, true},
+		{User{Username: "x"}, false},
+		{Address{Street: NullString{Valid: true}}, false},
+	}
+	for i, test := range tests {
+		if got := ReflectOn(test.value).IsZero(); got != test.zero {
+			t.Errorf("#%d IsZero(%#v) : got %v, want %v", i, test.value, got, test.zero)
+		}
+	}
+	if !ReflectOnPtr(&nilError).IsZero() {
+		t.Errorf("IsZero : nil interface should be zero")
+	}
+	nilError = ErrSyntax
+	if ReflectOnPtr(&nilError).IsZero() {
+		t.Errorf("IsZero : non nil interface should not be zero")
+	}
+
+	user := User{Username: "badu"}
+	user.Id = 3
+	v := ReflectOnPtr(&user)
+	if !ToStruct(v).FieldByName("Username").SetZero() || user.Username != "" || user.Id != 3 {
+		t.Errorf("SetZero : field : got %+v", user)
+	}
+	if !v.SetZero() || user.Id != 0 || !v.IsZero() {
+		t.Errorf("SetZero : struct : got %+v", user)
+	}
+
+	shouldPanic := func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("SetZero : should panic on a value which is not settable")
+			}
+		}()
+		ReflectOn(user).SetZero()
+	}
+	shouldPanic()
+}
+
+func TestCoerce(t *testing.T) {
+	type Port uint16
+	type Name string
+	tests := []struct {
+		value interface{}
+		to    interface{}
+		want  interface{}
+		err   error
+	}{
+		{"42", int(0), int(42), nil},
+		{" -7 ", int8(0), int8(-7), nil},
+		{"0x1F", uint32(0), uint32(31), nil},
+		{"0x1f", int(0), int(31), nil},
+		{"-0b101", int(0), int(-5), nil},
+		{"0o17", uint(0), uint(15), nil},
+		{"010", int(0), int(10), nil},
+		{"010", uint8(0), uint8(10), nil},
+		{"010", float64(0), float64(10), nil},
+		{"08", int(0), int(8), nil},
+		{"09", uint16(0), uint16(9), nil},
+		{"1e3", int64(0), int64(1000), nil},
+		{"8080", Port(0), Port(8080), nil},
+		{"2.5", float32(0), float32(2.5), nil},
+		{"true", false, true, nil},
+		{"text", Name(""), Name("text"), nil},
+		{42, "", "42", nil},
+		{int8(-3), "", "-3", nil},
+		{uint64(18446744073709551615), "", "18446744073709551615", nil},
+		{float32(0.1), "", "0.1", nil},
+		{1.5, Name(""), Name("1.5"), nil},
+		{false, "", "false", nil},
+		{true, uint8(0), uint8(1), nil},
+		{int64(1), true, true, nil},
+		{uint(0), true, false, nil},
+		{int64(-128), int8(0), int8(-128), nil},
+		{uint8(200), int16(0), int16(200), nil},
+		{int32(7), float64(0), float64(7), nil},
+		{float64(255), uint8(0), uint8(255), nil},
+		{float32(-1), int(0), int(-1), nil},
+		{3.4e38, float32(0), float32(3.4e38), nil},
+
+		{"128", int8(0), nil, ErrRange},
+		{"-1", uint(0), nil, ErrRange},
+		{"70000", Port(0), nil, ErrRange},
+		{"9223372036854775808", int64(0), nil, ErrRange},
+		{"1e39", float32(0), nil, ErrRange},
+		{int64(-129), int8(0), nil, ErrRange},
+		{int(-1), uint64(0), nil, ErrRange},
+		{uint64(1 << 63), int64(0), nil, ErrRange},
+		{uint16(256), uint8(0), nil, ErrRange},
+		{1e20, int64(0), nil, ErrRange},
+		{-1.0, uint(0), nil, ErrRange},
+		{1e39, float32(0), nil, ErrRange},
+		{2, false, nil, ErrRange},
+		{"abc", int(0), nil, ErrSyntax},
+		{"", float64(0), nil, ErrSyntax},
+		{"yes", false, nil, ErrSyntax},
+		{"1.5", int(0), nil, ErrNotConvertible},
+		{2.5, int(0), nil, ErrNotConvertible},
+		{[]int{1}, "", nil, ErrNotConvertible},
+		{"1", []int{}, nil, ErrNotConvertible},
+		{complex(1, 0), float64(0), nil, ErrNotConvertible},
+	}
+	for i, test := range tests {
+		got, err := Coerce(ReflectOn(test.value), TypeOf(test.to))
+		if err != test.err {
+			t.Errorf("#%d Coerce(%#v, %T) : error %v, want %v", i, test.value, test.to, err, test.err)
+			continue
+		}
+		if err == nil && got.Interface() != test.want {
+			t.Errorf("#%d Coerce(%#v, %T) : got %#v, want %#v", i, test.value, test.to, got.Interface(), test.want)
+		}
+	}
+
+	var held interface{} = "12"
+	if got, err := Coerce(ReflectOnPtr(&held), TypeOf(0)); err != nil || got.Interface() != 12 {
+		t.Errorf("Coerce of an interface : got %v, %v", got, err)
+	}
+	if _, err := Coerce(Value{}, TypeOf(0)); err != ErrInvalidValue {
+		t.Errorf("Coerce of an invalid value : got %v, want %v", err, ErrInvalidValue)
+	}
+}
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return ErrSyntax
+	}
+	return nil
+}
+
+func TestSetFromString(t *testing.T) {
+	type config struct {
+		Name     string
+		Port     uint16
+		Ratio    float32
+		Debug    bool
+		Point    complex64
+		Timeout  time.Duration
+		Since    time.Time
+		Retries  *int
+		Hosts    []string
+		Weights  [3]int8
+		Limits   map[string]int
+		Levels   map[level][]level
+		Level    level
+		Raw      []byte
+		Anything interface{}
+		private  int
+	}
+	var cfg config
+	v := ReflectOnPtr(&cfg)
+	fields := ToStruct(v)
+	values := []struct {
+		field string
+		text  string
+	}{
+		{"Name", " spaced "},
+		{"Port", "8080"},
+		{"Ratio", "0.25"},
+		{"Debug", "true"},
+		{"Point", "(1-2.5i)"},
+		{"Timeout", "1m30s"},
+		{"Since", "2018-03-04T05:06:07Z"},
+		{"Retries", "3"},
+		{"Hosts", `a, "b,c" , "d=\"e\""`},
+		{"Weights", "1,-2"},
+		{"Limits", `cpu=2, "mem ory"=-1`},
+		{"Levels", "low=high, high="},
+		{"Level", "high"},
+		{"Raw", "bytes"},
+		{"Anything", "text"},
+	}
+	for _, value := range values {
+		if err := SetFromString(fields.FieldByName(value.field), value.text); err != nil {
+			t.Fatalf("SetFromString(%s, %q) : %v", value.field, value.text, err)
+		}
+	}
+	since := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	if cfg.Name != " spaced " || cfg.Port != 8080 || cfg.Ratio != 0.25 || !cfg.Debug || cfg.Point != complex(1, -2.5) ||
+		cfg.Timeout != 90*time.Second || !cfg.Since.Equal(since) || cfg.Retries == nil || *cfg.Retries != 3 ||
+		cfg.Level != 2 || string(cfg.Raw) != "bytes" || cfg.Anything != "text" {
+		t.Errorf("SetFromString : scalars : got %+v", cfg)
+	}
+	if fmt.Sprint(cfg.Hosts) != `[a b,c d="e"]` || cfg.Weights != [3]int8{1, -2, 0} {
+		t.Errorf("SetFromString : lists : got %q and %v", cfg.Hosts, cfg.Weights)
+	}
+	if len(cfg.Limits) != 2 || cfg.Limits["cpu"] != 2 || cfg.Limits["mem ory"] != -1 {
+		t.Errorf("SetFromString : map : got %v", cfg.Limits)
+	}
+	if len(cfg.Levels) != 2 || fmt.Sprint(cfg.Levels[1]) != "[2]" || len(cfg.Levels[2]) != 0 {
+		t.Errorf("SetFromString : map of unmarshalers : got %v", cfg.Levels)
+	}
+
+	retries := cfg.Retries
+	if err := SetFromString(fields.FieldByName("Retries"), "5"); err != nil || cfg.Retries != retries || *retries != 5 {
+		t.Errorf("SetFromString : non nil pointer should be reused")
+	}
+
+	failures := []struct {
+		field string
+		text  string
+		err   error
+	}{
+		{"Port", "70000", ErrRange},
+		{"Port", "port", ErrSyntax},
+		{"Timeout", "90", ErrSyntax},
+		{"Since", "2018-03-04", ErrSyntax},
+		{"Point", "1+xi", ErrSyntax},
+		{"Hosts", `"open`, ErrSyntax},
+		{"Weights", "1,2,3,4", ErrRange},
+		{"Weights", "1,300", ErrRange},
+		{"Limits", "cpu", ErrSyntax},
+		{"Level", "medium", ErrSyntax},
+		{"private", "1", ErrUnexported},
+	}
+	for _, failure := range failures {
+		if err := SetFromString(fields.FieldByName(failure.field), failure.text); err != failure.err {
+			t.Errorf("SetFromString(%s, %q) : got %v, want %v", failure.field, failure.text, err, failure.err)
+		}
+	}
+	weights := [4]int{9, 9, 9, 9}
+	if err := SetFromString(ReflectOnPtr(&weights), "1,2"); err != nil || weights != [4]int{1, 2, 0, 0} {
+		t.Errorf("SetFromString : missing array items should be zero : got %v, %v", weights, err)
+	}
+	if err := SetFromString(ReflectOnPtr(&weights), "5,x"); err != ErrSyntax || weights != [4]int{1, 2, 0, 0} {
+		t.Errorf("SetFromString : a failure should leave the array untouched : got %v, %v", weights, err)
+	}
+	if err := SetFromString(Value{}, ""); err != ErrInvalidValue {
+		t.Errorf("SetFromString : invalid value : got %v", err)
+	}
+	if err := SetFromString(ReflectOn(0), "1"); err != ErrNotAssignable {
+		t.Errorf("SetFromString : not settable : got %v, want %v", err, ErrNotAssignable)
+	}
+	var channel chan int
+	if err := SetFromString(ReflectOnPtr(&channel), "1"); err != ErrNotConvertible {
+		t.Errorf("SetFromString : chan : got %v, want %v", err, ErrNotConvertible)
+	}
+}
+
+// numErrorKind returns "range", "syntax" or "" for the errors of this package and of strconv.
+func numErrorKind(err error) string {
+	switch err := err.(type) {
+	case nil:
+		return ""
+	case NumError:
+		if err.Err == ErrRange {
+			return "range"
+		}
+	case *strconv.NumError:
+		if err.Err == strconv.ErrRange {
+			return "range"
+		}
+	}
+	return "syntax"
+}
+
+func TestParseNumbers(t *testing.T) {
+	integers := []string{"", "0", "-0", "+5", "-5", "0x1F", "0b101", "0o17", "017", "08", "0x", "1_000", "_1", "1__0", "0x_1f", "1_",
+		"127", "128", "-128", "-129", "255", "256", "9223372036854775807", "9223372036854775808", "-9223372036854775809",
+		"18446744073709551615", "18446744073709551616", "zz", "ZZ", "-", "--1", "1e3", " 1"}
+	for _, s := range integers {
+		for _, base := range []int{0, 2, 10, 16, 36} {
+			for _, bits := range []int{0, 8, 16, 32, 64} {
+				i, err := ParseInt(s, base, bits)
+				wantI, wantErr := strconv.ParseInt(s, base, bits)
+				if i != wantI || numErrorKind(err) != numErrorKind(wantErr) {
+					t.Errorf("ParseInt(%q, %d, %d) : got %d, %v, want %d, %v", s, base, bits, i, err, wantI, wantErr)
+				}
+				u, err := ParseUintBytes([]byte(s), base, bits)
+				wantU, wantErr := strconv.ParseUint(s, base, bits)
+				if u != wantU || numErrorKind(err) != numErrorKind(wantErr) {
+					t.Errorf("ParseUint(%q, %d, %d) : got %d, %v, want %d, %v", s, base, bits, u, err, wantU, wantErr)
+				}
+			}
+		}
+	}
+
+	floats := []string{"", "0", "-0", "1.5", ".5", "5.", ".", "1e", "1e-5", "1E5", "inf", "-Inf", "+INF", "infinity", "NaN",
+		"1.7976931348623157e308", "1.7976931348623159e308", "4.9e-324", "2e-324", "1e-400", "3.4028235e38", "3.5e38",
+		"1.401298464324817e-45", "0.1", "123456789012345678901234567890",
+		"1.00000000000000011102230246251565404236316680908203125", // exactly halfway between two float64
+		"1.00000000000000011102230246251565404236316680908203126",
+		"x", "1x", "1.2.3", "+-1", "1e1000000000",
+		"1_000", "1_0.5", "-1_000e1_0", "0_1", "1.5_5", "_1", "1_", "1__0", "1_.5", "1._5", "1_e5", "1e_5", "1e1_", "in_f"}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		floats = append(floats, strconv.FormatFloat(math.Float64frombits(random.Uint64()), 'e', random.Intn(25), 64))
+	}
+	for _, s := range floats {
+		for _, bits := range []int{32, 64} {
+			f, err := ParseFloat(s, bits)
+			want, wantErr := strconv.ParseFloat(s, bits)
+			if math.Float64bits(f) != math.Float64bits(want) && !(f != f && want != want) || numErrorKind(err) != numErrorKind(wantErr) {
+				t.Errorf("ParseFloat(%q, %d) : got %v, %v, want %v, %v", s, bits, f, err, want, wantErr)
+			}
+		}
+	}
+
+	for _, s := range []string{"1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False", "", "yes", "tRUE"} {
+		b, err := ParseBoolBytes([]byte(s))
+		want, wantErr := strconv.ParseBool(s)
+		if b != want || numErrorKind(err) != numErrorKind(wantErr) {
+			t.Errorf("ParseBool(%q) : got %v, %v, want %v, %v", s, b, err, want, wantErr)
+		}
+	}
+
+	if _, err := ParseInt("300", 10, 8); err == nil || err.Error() != "reflect.ParseInt: value out of range" {
+		t.Errorf("ParseInt error : got %v", err)
+	}
+	_, floatErr := ParseFloat("x", 64)
+	if numErr, ok := floatErr.(NumError); !ok || numErr.Func != "ParseFloat" || numErr.Err != ErrSyntax {
+		t.Errorf("ParseFloat error : got %#v", floatErr)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		ParseInt("-0x7f", 0, 8)
+		ParseInt("999", 10, 8)
+		ParseUint("zz", 10, 64)
+		ParseFloat("1.00000000000000011102230246251565404236316680908203125", 64)
+		ParseFloat("1e400", 32)
+		ParseBool("maybe")
+	})
+	if allocs != 0 {
+		t.Errorf("parsing allocates %v times, want 0", allocs)
+	}
+}
+
+var quoteTests = []struct {
+	in    string
+	out   string
+	ascii string
+}{
+	{"", `""`, `""`},
+	{"\a\b\f\r\n\t\v", `"\a\b\f\r\n\t\v"`, `"\a\b\f\r\n\t\v"`},
+	{"\\\"'", `"\\\"'"`, `"\\\"'"`},
+	{"\x00\x7f", `"\x00\u007f"`, `"\x00\u007f"`},
+	{"\xff\xed\xa0\x80", `"\xff\xed\xa0\x80"`, `"\xff\xed\xa0\x80"`},
+	{"Hello, 世界", `"Hello, 世界"`, `"Hello, \u4e16\u754c"`},
+	{"\u263a\U0010ffff\u00a0", `"☺\U0010ffff\u00a0"`, `"\u263a\U0010ffff\u00a0"`},
+}
+
+func TestQuote(t *testing.T) {
+	for _, test := range quoteTests {
+		if got := Quote(test.in); got != test.out {
+			t.Errorf("Quote(%q) : got %s, want %s", test.in, got, test.out)
+		}
+		if got := QuoteToASCII(test.in); got != test.ascii {
+			t.Errorf("QuoteToASCII(%q) : got %s, want %s", test.in, got, test.ascii)
+		}
+		if got := string(AppendQuote([]byte("abc"), test.in)); got != "abc"+test.out {
+			t.Errorf("AppendQuote(%q) : got %s, want abc%s", test.in, got, test.out)
+		}
+	}
+
+	runes := []struct {
+		in  rune
+		out string
+	}{
+		{'a', `'a'`},
+		{'\'', `'\''`},
+		{'"', `'"'`},
+		{'\n', `'\n'`},
+		{'☺', `'☺'`},
+		{0x10FFFF, `'\U0010ffff'`},
+		{0x110000, `'�'`},
+		{-1, `'�'`},
+	}
+	for _, test := range runes {
+		if got := QuoteRune(test.in); got != test.out {
+			t.Errorf("QuoteRune(%q) : got %s, want %s", test.in, got, test.out)
+		}
+	}
+
+	buf := make([]byte, 0, 64)
+	if allocs := testing.AllocsPerRun(100, func() { AppendQuote(buf, "Hello, \x00 世界") }); allocs != 0 {
+		t.Errorf("AppendQuote allocates %v times, want 0", allocs)
+	}
+
+	roundTrip := func(s string, r rune) bool {
+		if got, err := Unquote(Quote(s)); err != nil || got != s {
+			return false
+		}
+		if got, err := Unquote(QuoteToASCII(s)); err != nil || got != s {
+			return false
+		}
+		got, err := Unquote(QuoteRune(r))
+		return err == nil && (got == string(r) || !utf8.ValidRune(r) && got == "\uFFFD")
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConvertSliceToArray(t *testing.T) {
+	type Bytes []byte
+	type Quad [4]byte
+	slice := []int{1, 2, 3, 4}
+	v := ReflectOn(slice)
+
+	arrayPtrType, arrayType := TypeOf((*[3]int)(nil)), TypeOf([3]int{})
+	if !v.Type.ConvertibleTo(arrayPtrType) || !v.Type.ConvertibleTo(arrayType) || !v.Type.ConvertibleTo(TypeOf([5]int{})) {
+		t.Errorf("ConvertibleTo : []int should be convertible to *[3]int, [3]int and [5]int")
+	}
+	if v.Type.ConvertibleTo(TypeOf([3]int64{})) || v.Type.ConvertibleTo(TypeOf((*[3]uint)(nil))) || arrayType.ConvertibleTo(v.Type) {
+		t.Errorf("ConvertibleTo : element types must be identical, and arrays are not convertible to slices")
+	}
+
+	ptr := Convert(v, arrayPtrType).Interface().(*[3]int)
+	if *ptr != [3]int{1, 2, 3} {
+		t.Errorf("Convert to *[3]int : got %v", *ptr)
+	}
+	ptr[0] = 10
+	if slice[0] != 10 {
+		t.Errorf("Convert to *[3]int : the array pointer should share the memory of the slice")
+	}
+
+	array := Convert(v, arrayType)
+	if array.CanAddr() || array.Interface().([3]int) != [3]int{10, 2, 3} {
+		t.Errorf("Convert to [3]int : got %v", array.Interface())
+	}
+	slice[1] = 20
+	if array.Interface().([3]int)[1] != 2 {
+		t.Errorf("Convert to [3]int : the array should be a copy of the slice")
+	}
+
+	if quad := Convert(ReflectOn(Bytes("abcd")), TypeOf(Quad{})).Interface().(Quad); quad != (Quad{'a', 'b', 'c', 'd'}) {
+		t.Errorf("Convert named types : got %v", quad)
+	}
+	if empty := Convert(ReflectOn([]int(nil)), TypeOf((*[0]int)(nil))).Interface().(*[0]int); empty != nil {
+		t.Errorf("Convert nil slice to *[0]int : got %v, want nil", empty)
+	}
+
+	if _, err := TryConvert(v, TypeOf([5]int{})); err != ErrOutOfRange {
+		t.Errorf("TryConvert to a longer array : got %v, want %v", err, ErrOutOfRange)
+	}
+	if _, err := TryConvert(v, TypeOf((*[5]int)(nil))); err != ErrOutOfRange {
+		t.Errorf("TryConvert to a longer array pointer : got %v, want %v", err, ErrOutOfRange)
+	}
+	if got, err := TryConvert(v, TypeOf([4]int{})); err != nil || got.Interface().([4]int) != [4]int{10, 20, 3, 4} {
+		t.Errorf("TryConvert to [4]int : got %v, %v", got, err)
+	}
+
+	shouldPanic := func(typ *RType) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Convert to %v : should panic on a shorter slice", typ)
+			}
+		}()
+		Convert(v, typ)
+	}
+	shouldPanic(TypeOf([5]int{}))
+	shouldPanic(TypeOf((*[5]int)(nil)))
+}
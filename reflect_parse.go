@@ -0,0 +1,346 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"math"
+)
+
+var (
+	// the errors are boxed once, so that failing does not allocate either. They hold a NumError by value :
+	// callers get copies of it, so they cannot change the errors returned to others.
+	errParseIntSyntax   error = NumError{Func: "ParseInt", Err: ErrSyntax}
+	errParseIntRange    error = NumError{Func: "ParseInt", Err: ErrRange}
+	errParseUintSyntax  error = NumError{Func: "ParseUint", Err: ErrSyntax}
+	errParseUintRange   error = NumError{Func: "ParseUint", Err: ErrRange}
+	errParseFloatSyntax error = NumError{Func: "ParseFloat", Err: ErrSyntax}
+	errParseFloatRange  error = NumError{Func: "ParseFloat", Err: ErrRange}
+	errParseBoolSyntax  error = NumError{Func: "ParseBool", Err: ErrSyntax}
+)
+
+// Error implements the error interface.
+func (e NumError) Error() string {
+	return "reflect." + e.Func + ": " + e.Err.Error()
+}
+
+// ParseInt interprets a string s in the given base (0, 2 to 36) and bit size (0 to 64) and returns the corresponding value i.
+// It works like strconv.ParseInt, without allocating.
+//
+// If the base argument is 0, the true base is implied by the string's prefix : 2 for "0b", 8 for "0" or "0o",
+// 16 for "0x", and 10 otherwise. Also, for argument base 0 only, underscore characters are permitted as digit separators.
+// The bitSize argument specifies the integer type that the result must fit into : 0, 8, 16, 32, and 64 correspond to
+// int, int8, int16, int32, and int64.
+//
+// The errors are NumError values : their Err is ErrSyntax if s is empty or contains invalid digits, and ErrRange
+// if the value does not fit, in which case the returned value is the maximum magnitude integer of the appropriate
+// bitSize and sign.
+func ParseInt(s string, base int, bitSize int) (int64, error) {
+	i, err := parseInt(s, base, bitSize)
+	switch err {
+	case ErrSyntax:
+		return i, errParseIntSyntax
+	case ErrRange:
+		return i, errParseIntRange
+	}
+	return i, nil
+}
+
+// ParseUint is like ParseInt but for unsigned numbers. A sign prefix is not permitted.
+func ParseUint(s string, base int, bitSize int) (uint64, error) {
+	n, err := parseUint(s, base, bitSize)
+	switch err {
+	case ErrSyntax:
+		return n, errParseUintSyntax
+	case ErrRange:
+		return n, errParseUintRange
+	}
+	return n, nil
+}
+
+// ParseFloat converts the decimal string s to a floating-point number with the precision specified by bitSize :
+// 32 for float32, or 64 for float64. When bitSize=32, the result still has type float64, but it will be convertible
+// to float32 without changing its value. It works like strconv.ParseFloat (for decimal numbers), without allocating :
+// underscores are accepted as digit separators, as in Go literals ("1_000.5").
+//
+// The result is the nearest floating-point number rounded using IEEE754 unbiased rounding.
+// "Inf", "+Inf", "-Inf", "Infinity" and "NaN" are accepted in any case.
+//
+// The errors are NumError values : their Err is ErrSyntax if s is not syntactically well-formed, and ErrRange if s is
+// syntactically well-formed but more than 1/2 ULP away from the largest floating point number of the given size,
+// in which case the returned value is ±Inf.
+func ParseFloat(s string, bitSize int) (float64, error) {
+	f, err := parseFloat(s, bitSize)
+	switch err {
+	case ErrSyntax:
+		return f, errParseFloatSyntax
+	case ErrRange:
+		return f, errParseFloatRange
+	}
+	return f, nil
+}
+
+// ParseBool returns the boolean value represented by the string.
+// It accepts 1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False. Any other value returns a NumError (of ErrSyntax).
+func ParseBool(s string) (bool, error) {
+	switch s {
+	case "1", "t", "T", "TRUE", "true", "True":
+		return true, nil
+	case "0", "f", "F", "FALSE", "false", "False":
+		return false, nil
+	}
+	return false, errParseBoolSyntax
+}
+
+// ParseIntBytes is ParseInt for a byte slice.
+func ParseIntBytes(b []byte, base int, bitSize int) (int64, error) {
+	return ParseInt(BytesToString(b), base, bitSize)
+}
+
+// ParseUintBytes is ParseUint for a byte slice.
+func ParseUintBytes(b []byte, base int, bitSize int) (uint64, error) {
+	return ParseUint(BytesToString(b), base, bitSize)
+}
+
+// ParseFloatBytes is ParseFloat for a byte slice.
+func ParseFloatBytes(b []byte, bitSize int) (float64, error) {
+	return ParseFloat(BytesToString(b), bitSize)
+}
+
+// ParseBoolBytes is ParseBool for a byte slice.
+func ParseBoolBytes(b []byte) (bool, error) {
+	return ParseBool(BytesToString(b))
+}
+
+// isRangeError reports whether err is a NumError for a value out of range.
+func isRangeError(err error) bool {
+	numErr, ok := err.(NumError)
+	return ok && numErr.Err == ErrRange
+}
+
+// parseUint returns ErrSyntax or ErrRange, which ParseUint and ParseInt turn into their own errors.
+func parseUint(s string, base int, bitSize int) (uint64, error) {
+	if len(s) == 0 {
+		return 0, ErrSyntax
+	}
+
+	base0 := base == 0
+	s0 := s
+	switch {
+	case 2 <= base && base <= 36:
+		// valid base; nothing to do
+
+	case base == 0:
+		// Look for octal, hex prefix.
+		base = 10
+		if s[0] == '0' {
+			switch {
+			case len(s) >= 3 && lowerASCII(s[1]) == 'b':
+				base = 2
+				s = s[2:]
+			case len(s) >= 3 && lowerASCII(s[1]) == 'o':
+				base = 8
+				s = s[2:]
+			case len(s) >= 3 && lowerASCII(s[1]) == 'x':
+				base = 16
+				s = s[2:]
+			default:
+				base = 8
+			}
+		}
+
+	default:
+		if willPrintDebug {
+			panic("reflect.ParseUint: invalid base " + I2A(base, -1))
+		}
+		return 0, ErrSyntax
+	}
+
+	if bitSize == 0 {
+		bitSize = uintSize
+	} else if bitSize < 0 || bitSize > 64 {
+		if willPrintDebug {
+			panic("reflect.ParseUint: invalid bit size " + I2A(bitSize, -1))
+		}
+		return 0, ErrSyntax
+	}
+
+	// Cutoff is the smallest number such that cutoff*base > maxUint64.
+	cutoff := uint64(math.MaxUint64)/uint64(base) + 1
+	maxVal := uint64(1)<<uint(bitSize) - 1
+
+	underscores := false
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		var d byte
+		c := s[i]
+		switch {
+		case c == '_' && base0:
+			underscores = true
+			continue
+		case '0' <= c && c <= '9':
+			d = c - '0'
+		case 'a' <= lowerASCII(c) && lowerASCII(c) <= 'z':
+			d = lowerASCII(c) - 'a' + 10
+		default:
+			return 0, ErrSyntax
+		}
+
+		if d >= byte(base) {
+			return 0, ErrSyntax
+		}
+
+		if n >= cutoff {
+			// n*base overflows
+			return maxVal, ErrRange
+		}
+		n *= uint64(base)
+
+		n1 := n + uint64(d)
+		if n1 < n || n1 > maxVal {
+			// n+v overflows
+			return maxVal, ErrRange
+		}
+		n = n1
+	}
+
+	if underscores && !underscoreOK(s0) {
+		return 0, ErrSyntax
+	}
+	return n, nil
+}
+
+// parseInt returns ErrSyntax or ErrRange, which ParseInt turns into its own errors.
+func parseInt(s string, base int, bitSize int) (int64, error) {
+	if len(s) == 0 {
+		return 0, ErrSyntax
+	}
+
+	// Pick off leading sign.
+	neg := false
+	if s[0] == '+' {
+		s = s[1:]
+	} else if s[0] == '-' {
+		neg = true
+		s = s[1:]
+	}
+
+	// Convert unsigned and check range.
+	un, err := parseUint(s, base, bitSize)
+	if err != nil && err != ErrRange {
+		return 0, err
+	}
+
+	if bitSize == 0 {
+		bitSize = uintSize
+	}
+
+	cutoff := uint64(1 << uint(bitSize-1))
+	if !neg && un >= cutoff {
+		return int64(cutoff - 1), ErrRange
+	}
+	if neg && un > cutoff {
+		return -int64(cutoff), ErrRange
+	}
+	n := int64(un)
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// parseFloat returns ErrSyntax or ErrRange, which ParseFloat turns into its own errors.
+func parseFloat(s string, bitSize int) (float64, error) {
+	if val, ok := special(s); ok {
+		return val, nil
+	}
+
+	// Parse mantissa and exponent, then try pure floating-point arithmetic conversion.
+	mantissa, exp, neg, trunc, ok := readFloat(s)
+	if !ok {
+		return 0, ErrSyntax
+	}
+	if !trunc {
+		if bitSize == 32 {
+			if f, ok := atof32exact(mantissa, exp, neg); ok {
+				return float64(f), nil
+			}
+		} else if f, ok := atof64exact(mantissa, exp, neg); ok {
+			return f, nil
+		}
+	}
+
+	// Slow fallback.
+	var d decimal
+	if !d.set(s) {
+		return 0, ErrSyntax
+	}
+	var f float64
+	var overflow bool
+	if bitSize == 32 {
+		var b uint64
+		b, overflow = d.floatBits(&float32info)
+		f = float64(math.Float32frombits(uint32(b)))
+	} else {
+		var b uint64
+		b, overflow = d.floatBits(&float64info)
+		f = math.Float64frombits(b)
+	}
+	if overflow {
+		return f, ErrRange
+	}
+	return f, nil
+}
+
+// underscoreOK reports whether the underscores in s are allowed.
+// Checking them in this one function lets all the parsers skip over them simply.
+// Underscore must appear only between digits or between a base prefix and a digit.
+func underscoreOK(s string) bool {
+	// saw tracks the last character (class) we saw:
+	// ^ for beginning of number,
+	// 0 for a digit or base prefix,
+	// _ for an underscore,
+	// ! for none of the above.
+	saw := '^'
+	i := 0
+
+	// Optional sign.
+	if len(s) >= 1 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	// Optional base prefix.
+	hex := false
+	if len(s) >= 2 && s[0] == '0' && (lowerASCII(s[1]) == 'b' || lowerASCII(s[1]) == 'o' || lowerASCII(s[1]) == 'x') {
+		i = 2
+		saw = '0' // base prefix counts as a digit for "underscore as digit separator"
+		hex = lowerASCII(s[1]) == 'x'
+	}
+
+	// Number proper.
+	for ; i < len(s); i++ {
+		// Digits are always okay.
+		if '0' <= s[i] && s[i] <= '9' || hex && 'a' <= lowerASCII(s[i]) && lowerASCII(s[i]) <= 'f' {
+			saw = '0'
+			continue
+		}
+		// Underscore must follow digit.
+		if s[i] == '_' {
+			if saw != '0' {
+				return false
+			}
+			saw = '_'
+			continue
+		}
+		// Underscore must also be followed by digit.
+		if saw == '_' {
+			return false
+		}
+		// Saw non-digit, non-underscore.
+		saw = '!'
+	}
+	return saw != '_'
+}
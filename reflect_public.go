@@ -93,6 +93,8 @@ func TypeOf(i interface{}) *RType {
 // Convert returns the value v converted to type t.
 // If the usual Go conversion rules do not allow conversion
 // of the value v to type t, Convert panics.
+// Converting a slice to an array pointer shares the memory of the slice, while converting it to an array copies it :
+// both panic if the length of the slice is less than the length of the array.
 func Convert(v Value, typ *RType) Value {
 	destKind := typ.Kind()
 	srcKind := v.Type.Kind()
@@ -150,6 +152,16 @@ func Convert(v Value, typ *RType) Value {
 				return makeString(v.ro(), string(*(*[]rune)(v.Ptr)), typ) // // convert operation: []rune -> string
 			}
 		}
+		if destKind == Ptr && typ.Deref().Kind() == Array && typ.Deref().ConvToArray().ElemType == sliceElem {
+			return cvtSliceArrayPtr(v, typ) // convert operation: []T -> *[N]T
+		}
+		if destKind == Array && typ.ConvToArray().ElemType == sliceElem {
+			return cvtSliceArray(v, typ) // convert operation: []T -> [N]T
+		}
+	case Chan:
+		if destKind == Chan && v.Type.specialChannelAssignability(typ) {
+			return cvtDirect(v, typ) // convert operation: chan T -> <-chan T, chan<- T
+		}
 	}
 
 	// dst and src have same underlying type.
@@ -175,6 +187,22 @@ func Convert(v Value, typ *RType) Value {
 	panic("reflect.Value.Convert: value of type ") // + TypeToString(v.Type) + " cannot be converted to type " + TypeToString(t))
 }
 
+// TryConvert returns the value v converted to type typ, like Convert,
+// but returns an error instead of panicking when the conversion is not allowed
+// (ErrOutOfRange for a slice shorter than the array it is converted to).
+func TryConvert(v Value, typ *RType) (Value, error) {
+	if !v.IsValid() || typ == nil {
+		return Value{}, ErrInvalidValue
+	}
+	if !v.Type.ConvertibleTo(typ) {
+		return Value{}, ErrNotConvertible
+	}
+	if v.Kind() == Slice && (typ.Kind() == Array || typ.Kind() == Ptr) && sliceArrayLen(typ) > ToSlice(v).Len() {
+		return Value{}, ErrOutOfRange
+	}
+	return Convert(v, typ), nil
+}
+
 // syntactic sugar
 func ToMap(v Value) MapValue {
 	if v.Type == nil {
@@ -192,6 +220,23 @@ func ToMap(v Value) MapValue {
 	return MapValue{Value: v}
 }
 
+// syntactic sugar
+func ToChan(v Value) ChanValue {
+	if v.Type == nil {
+		if willPrintDebug {
+			println("Value->ChanValue called on a nil type.")
+		}
+		return ChanValue{}
+	}
+	if !v.IsValid() || !v.isExported() || v.Kind() != Chan {
+		if willPrintDebug {
+			println("Value->ChanValue kind `" + StringKind(v.Kind()) + "` not chan, invalid or not exported.")
+		}
+		return ChanValue{}
+	}
+	return ChanValue{Value: v}
+}
+
 // syntactic sugar
 func ToSlice(v Value) SliceValue {
 	if v.Type == nil {
@@ -305,6 +350,59 @@ func MapOf(keyType, elemType *RType) *RType {
 	return &proto.RType
 }
 
+// ChanOf returns the channel type with the given direction and element type.
+// For example, if t represents int, ChanOf(RecvDir, t) represents <-chan int.
+//
+// The gc runtime imposes a limit of 64 kB on channel element types.
+// If t's size is equal to or exceeds this limit, ChanOf panics.
+func ChanOf(dir ChanDir, elemType *RType) *RType {
+	// This restriction is imposed by the gc compiler and the runtime.
+	if elemType.size >= 1<<16 {
+		if willPrintDebug {
+			panic("reflect.ChanOf: element size too large")
+		}
+		return nil
+	}
+
+	// Look in known types.
+	var typeName []byte
+	switch dir {
+	case SendDir:
+		typeName = byteSliceFromParams(sendChanStr, TypeToString(elemType))
+	case RecvDir:
+		typeName = byteSliceFromParams(recvChanStr, TypeToString(elemType))
+	case BothDir:
+		if elemType.Kind() == Chan && elemType.ChanDir() == RecvDir {
+			// chan (<-chan T) - otherwise it would read as <-chan (chan T)
+			typeName = byteSliceFromParams(chanStr, openPar, TypeToString(elemType), closePar)
+		} else {
+			typeName = byteSliceFromParams(chanStr, TypeToString(elemType))
+		}
+	default:
+		if willPrintDebug {
+			panic("reflect.ChanOf: invalid dir")
+		}
+		return nil
+	}
+	for _, existingType := range typesByString(typeName) {
+		chanType := existingType.convToChan()
+		if chanType.ElemType == elemType && chanType.dir == uintptr(dir) {
+			return existingType
+		}
+	}
+
+	// Make a channel type.
+	proto := emptyChanProto()
+	proto.extraTypeFlag = 0
+	proto.str = declareReflectName(newName(typeName))
+	proto.hash = fnv1(elemType.hash, 'c', byte(dir))
+	proto.ElemType = elemType
+	proto.dir = uintptr(dir)
+	proto.ptrToThis = 0
+
+	return &proto.RType
+}
+
 // SliceOf returns the slice type with element type t.
 // For example, if t represents int, SliceOf(t) represents []int.
 func SliceOf(typ *RType) *RType {
@@ -490,6 +588,460 @@ func ArrayOf(elem *RType, count int) *RType {
 	return &proto.RType
 }
 
+// FuncOf returns the function type with the given argument and result types.
+// For example if k represents int and e represents string,
+// FuncOf([]*RType{k}, []*RType{e}, false) represents func(int) string.
+//
+// The variadic argument controls whether the function is variadic. FuncOf
+// panics if the in[len(in)-1] does not represent a slice and variadic is
+// true.
+func FuncOf(in, out []*RType, variadic bool) *RType {
+	if variadic && (len(in) == 0 || in[len(in)-1].Kind() != Slice) {
+		if willPrintDebug {
+			panic("reflect.FuncOf: last arg of variadic func must be slice")
+		}
+		return nil
+	}
+
+	// Make a func type.
+	prototype := emptyFuncProto()
+	n := len(in) + len(out)
+
+	var (
+		fnType *funcType
+		args   []*RType
+	)
+	switch {
+	case n <= 4:
+		fixed := new(funcTypeFixed4)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	case n <= 8:
+		fixed := new(funcTypeFixed8)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	case n <= 16:
+		fixed := new(funcTypeFixed16)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	case n <= 32:
+		fixed := new(funcTypeFixed32)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	case n <= 64:
+		fixed := new(funcTypeFixed64)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	case n <= 128:
+		fixed := new(funcTypeFixed128)
+		args = fixed.args[:0:len(fixed.args)]
+		fnType = &fixed.funcType
+	default:
+		if willPrintDebug {
+			panic("reflect.FuncOf: too many arguments")
+		}
+		return nil
+	}
+	*fnType = prototype
+
+	// Build a hash and minimally populate fnType.
+	var hash uint32
+	for _, inParam := range in {
+		args = append(args, inParam)
+		hash = fnv1(hash, byte(inParam.hash>>24), byte(inParam.hash>>16), byte(inParam.hash>>8), byte(inParam.hash))
+	}
+	if variadic {
+		hash = fnv1(hash, 'v')
+	}
+	hash = fnv1(hash, '.')
+	for _, outParam := range out {
+		args = append(args, outParam)
+		hash = fnv1(hash, byte(outParam.hash>>24), byte(outParam.hash>>16), byte(outParam.hash>>8), byte(outParam.hash))
+	}
+	fnType.extraTypeFlag = 0
+	fnType.hash = hash
+	fnType.InLen = uint16(len(in))
+	fnType.OutLen = uint16(len(out))
+	if variadic {
+		fnType.OutLen |= 1 << 15
+	}
+
+	// Look in cache.
+	if cached, ok := funcLookupCache.m.Load(hash); ok {
+		for _, existingType := range cached.([]*RType) {
+			if fnType.haveIdenticalUnderlyingType(existingType, true) {
+				return existingType
+			}
+		}
+	}
+
+	// Not in cache, lock and retry.
+	funcLookupCache.Lock()
+	defer funcLookupCache.Unlock()
+	if cached, ok := funcLookupCache.m.Load(hash); ok {
+		for _, existingType := range cached.([]*RType) {
+			if fnType.haveIdenticalUnderlyingType(existingType, true) {
+				return existingType
+			}
+		}
+	}
+
+	addToCache := func(typ *RType) *RType {
+		var cachedTypes []*RType
+		if cached, ok := funcLookupCache.m.Load(hash); ok {
+			cachedTypes = cached.([]*RType)
+		}
+		funcLookupCache.m.Store(hash, append(cachedTypes, typ))
+		return typ
+	}
+
+	// Look in known types for the same string representation.
+	typeName := funcStr(fnType)
+	for _, existingType := range typesByString(typeName) {
+		if fnType.haveIdenticalUnderlyingType(existingType, true) {
+			return addToCache(existingType)
+		}
+	}
+
+	// Populate the remaining fields of fnType and store in cache.
+	fnType.str = declareReflectName(newName(typeName))
+	fnType.ptrToThis = 0
+	return addToCache(&fnType.RType)
+}
+
+// StructOf returns the struct type containing fields.
+// The Offset and Index fields are ignored and computed as they would be
+// by the compiler.
+//
+// Embedded fields keep their layout and the embedded bit, but their methods
+// are not promoted to the new struct type.
+func StructOf(fields []StructField) *RType {
+	var (
+		hash       = fnv1(0, []byte(structStr)...)
+		size       uintptr
+		typeAlign  uint8
+		comparable = true
+		hashable   = true
+
+		fs      = make([]structField, len(fields))
+		repr    = make([]byte, 0, 64)
+		fset    = make(map[string]struct{}) // fields' names
+		pkgPath = ""
+
+		hasPtr    = false // records whether at least one struct-field is a pointer
+		hasGCProg = false // records whether a struct-field type has a GCProg
+	)
+
+	lastZero := uintptr(0)
+	repr = append(repr, structStr...)
+	for i, field := range fields {
+		if field.Name == "" {
+			if willPrintDebug {
+				panic("reflect.StructOf: field " + I2A(i, -1) + " has no name")
+			}
+			return nil
+		}
+		if !isValidFieldName(field.Name) {
+			if willPrintDebug {
+				panic("reflect.StructOf: field " + I2A(i, -1) + " has invalid name")
+			}
+			return nil
+		}
+		if field.Type == nil {
+			if willPrintDebug {
+				panic("reflect.StructOf: field " + I2A(i, -1) + " has no type")
+			}
+			return nil
+		}
+		f, ok := runtimeStructField(field)
+		if !ok {
+			return nil
+		}
+		fieldType := f.Type
+		if !fieldType.canHandleGC() {
+			hasGCProg = true
+		}
+		if fieldType.hasPointers() {
+			hasPtr = true
+		}
+		if field.PkgPath != "" {
+			if pkgPath == "" {
+				pkgPath = field.PkgPath
+			} else if pkgPath != field.PkgPath {
+				if willPrintDebug {
+					panic("reflect.StructOf: fields with different PkgPath " + pkgPath + " and " + field.PkgPath)
+				}
+				return nil
+			}
+		}
+
+		if field.Anonymous && fieldType.Kind() == Ptr {
+			// Embedded ** and *interface{} are illegal
+			if k := fieldType.Deref().Kind(); k == Ptr || k == Interface {
+				if willPrintDebug {
+					panic("reflect.StructOf: illegal embedded field type " + TypeToString(fieldType))
+				}
+				return nil
+			}
+		}
+		if _, dup := fset[field.Name]; dup {
+			if willPrintDebug {
+				panic("reflect.StructOf: duplicate field " + field.Name)
+			}
+			return nil
+		}
+		fset[field.Name] = struct{}{}
+
+		// Update string and hash : embedded fields are printed by type only, the way the compiler does.
+		hash = fnv1(hash, []byte(field.Name)...)
+		if !field.Anonymous {
+			repr = append(repr, ' ')
+			repr = append(repr, field.Name...)
+		}
+		hash = fnv1(hash, byte(fieldType.hash>>24), byte(fieldType.hash>>16), byte(fieldType.hash>>8), byte(fieldType.hash))
+		repr = append(repr, ' ')
+		repr = append(repr, fieldType.nomen()...)
+		if field.Tag != "" {
+			hash = fnv1(hash, []byte(field.Tag)...)
+			repr = append(repr, ' ')
+			repr = appendQuotedWith(repr, field.Tag, '"', false)
+		}
+		if i < len(fields)-1 {
+			repr = append(repr, ';')
+		}
+
+		comparable = comparable && (fieldType.alg.equal != nil)
+		hashable = hashable && (fieldType.alg.hash != nil)
+
+		offset := align(size, uintptr(fieldType.align))
+		if fieldType.align > typeAlign {
+			typeAlign = fieldType.align
+		}
+		size = offset + fieldType.size
+		f.offsetEmbed |= offset << 1
+
+		if fieldType.size == 0 {
+			lastZero = size
+		}
+
+		fs[i] = f
+	}
+
+	if size > 0 && lastZero == size {
+		// This is a non-zero sized struct that ends in a
+		// zero-sized field. We add an extra byte of padding,
+		// to ensure that taking the address of the final
+		// zero-sized field can't manufacture a pointer to the
+		// next object in the heap. See issue 9401.
+		size++
+	}
+
+	if len(fs) > 0 {
+		repr = append(repr, ' ')
+	}
+	repr = append(repr, '}')
+	hash = fnv1(hash, '}')
+
+	// Round the size up to be a multiple of the alignment.
+	size = align(size, uintptr(typeAlign))
+
+	// Make the struct type.
+	proto := emptyStructProto()
+	proto.fields = fs
+	proto.pkgPath = name{}
+	if pkgPath != "" {
+		proto.pkgPath = newName([]byte(pkgPath))
+	}
+	typ := &proto
+
+	// Look in cache.
+	if cached, ok := structLookupCache.m.Load(hash); ok {
+		for _, existingType := range cached.([]*RType) {
+			if typ.haveIdenticalUnderlyingType(existingType, true) {
+				return existingType
+			}
+		}
+	}
+
+	// Not in cache, lock and retry.
+	structLookupCache.Lock()
+	defer structLookupCache.Unlock()
+	if cached, ok := structLookupCache.m.Load(hash); ok {
+		for _, existingType := range cached.([]*RType) {
+			if typ.haveIdenticalUnderlyingType(existingType, true) {
+				return existingType
+			}
+		}
+	}
+
+	addToCache := func(t *RType) *RType {
+		var cachedTypes []*RType
+		if cached, ok := structLookupCache.m.Load(hash); ok {
+			cachedTypes = cached.([]*RType)
+		}
+		structLookupCache.m.Store(hash, append(cachedTypes, t))
+		return t
+	}
+
+	// Look in known types.
+	for _, existingType := range typesByString(repr) {
+		if typ.haveIdenticalUnderlyingType(existingType, true) {
+			return addToCache(existingType)
+		}
+	}
+
+	typ.str = declareReflectName(newName(repr))
+	typ.extraTypeFlag = 0
+	typ.hash = hash
+	typ.size = size
+	typ.ptrData = typePtrData(typ)
+	typ.align = typeAlign
+	typ.fieldAlign = typeAlign
+	typ.ptrToThis = 0
+
+	if hasGCProg {
+		lastPtrField := 0
+		for i := range fs {
+			if fs[i].Type.hasPointers() {
+				lastPtrField = i
+			}
+		}
+		prog := []byte{0, 0, 0, 0} // will be length of prog
+		var off uintptr
+		for i := range fs {
+			if i > lastPtrField {
+				// gcprog should not include anything for any field after
+				// the last field that contains pointer data
+				break
+			}
+			field := &fs[i]
+			if !field.Type.hasPointers() {
+				// Ignore pointerless fields.
+				continue
+			}
+			// Pad to start of this field with zeros.
+			fieldOffset := structFieldOffset(field)
+			if fieldOffset > off {
+				n := (fieldOffset - off) / PtrSize
+				prog = append(prog, 0x01, 0x00) // emit a 0 bit
+				if n > 1 {
+					prog = append(prog, 0x81)      // repeat previous bit
+					prog = appendVarint(prog, n-1) // n-1 times
+				}
+				off = fieldOffset
+			}
+
+			elemGC := (*[1 << 30]byte)(unsafe.Pointer(field.Type.gcData))[:]
+			elemPtrs := field.Type.ptrData / PtrSize
+			if field.Type.canHandleGC() {
+				// Element is small with pointer mask; use as literal bits.
+				mask := elemGC
+				// Emit 120-bit chunks of full bytes (max is 127 but we avoid using partial bytes).
+				var n uintptr
+				for n = elemPtrs; n > 120; n -= 120 {
+					prog = append(prog, 120)
+					prog = append(prog, mask[:15]...)
+					mask = mask[15:]
+				}
+				prog = append(prog, byte(n))
+				prog = append(prog, mask[:(n+7)/8]...)
+			} else {
+				// Element has GC program; emit one element.
+				elemProg := elemGC[4 : 4+*(*uint32)(unsafe.Pointer(&elemGC[0]))-1]
+				prog = append(prog, elemProg...)
+			}
+			off += field.Type.ptrData
+		}
+		prog = append(prog, 0)
+		*(*uint32)(unsafe.Pointer(&prog[0])) = uint32(len(prog) - 4)
+		typ.kind |= kindGCProg
+		typ.gcData = &prog[0]
+	} else {
+		typ.kind &^= kindGCProg
+		typ.gcData = nil
+		vec := new(bitVector)
+		typ.RType.addTypeBits(vec, 0)
+		if len(vec.data) > 0 {
+			typ.gcData = &vec.data[0]
+		}
+	}
+
+	if hasPtr {
+		typ.kind &^= kindNoPointers
+	} else {
+		typ.kind |= kindNoPointers
+	}
+
+	typ.alg = new(algo)
+	if hashable {
+		typ.alg.hash = func(p unsafe.Pointer, seed uintptr) uintptr {
+			o := seed
+			for i := range typ.fields {
+				field := &typ.fields[i]
+				o = field.Type.alg.hash(add(p, structFieldOffset(field)), o)
+			}
+			return o
+		}
+	}
+	if comparable {
+		typ.alg.equal = func(p, q unsafe.Pointer) bool {
+			for i := range typ.fields {
+				field := &typ.fields[i]
+				fieldOffset := structFieldOffset(field)
+				if !field.Type.alg.equal(add(p, fieldOffset), add(q, fieldOffset)) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	switch {
+	case len(fs) == 1 && !fs[0].Type.isDirectIface():
+		// structs of 1 direct iface type can be direct
+		typ.kind |= kindDirectIface
+	default:
+		typ.kind &^= kindDirectIface
+	}
+
+	return addToCache(&typ.RType)
+}
+
+// runtimeStructField converts a StructField descriptor into its runtime (encoded) counterpart.
+func runtimeStructField(field StructField) (structField, bool) {
+	if field.Anonymous && field.PkgPath != "" {
+		if willPrintDebug {
+			panic("reflect.StructOf: field \"" + field.Name + "\" is anonymous but has PkgPath set")
+		}
+		return structField{}, false
+	}
+
+	exported := field.PkgPath == ""
+	if exported {
+		// Best-effort check for misuse.
+		// Since this field will be treated as exported, not much harm done if Unicode lowercase slips through.
+		c := field.Name[0]
+		if 'a' <= c && c <= 'z' || c == '_' {
+			if willPrintDebug {
+				panic("reflect.StructOf: field \"" + field.Name + "\" is unexported but missing PkgPath")
+			}
+			return structField{}, false
+		}
+	}
+
+	offsetEmbed := uintptr(0)
+	if field.Anonymous {
+		offsetEmbed |= 1
+	}
+
+	addReflectOff(unsafe.Pointer(field.Type)) // install in runtime
+	return structField{
+		name:        newFieldName([]byte(field.Name), []byte(field.Tag), exported),
+		Type:        field.Type,
+		offsetEmbed: offsetEmbed,
+	}, true
+}
+
 // Get returns the value associated with key in the tag string.
 // If there is no such key in the tag, Get returns the empty string.
 // If the tag does not have the conventional format, the value
@@ -594,6 +1146,30 @@ func MakeMapWithSize(typ *RType, n int) MapValue {
 	return MapValue{Value: Value{Type: typ, Ptr: m, Flag: Flag(Map)}}
 }
 
+// MakeChan creates a new channel with the specified type and buffer size.
+func MakeChan(typ *RType, buffer int) ChanValue {
+	if typ.Kind() != Chan {
+		if willPrintDebug {
+			panic("reflect.MakeChan of non-chan type")
+		}
+		return ChanValue{}
+	}
+	if buffer < 0 {
+		if willPrintDebug {
+			panic("reflect.MakeChan: negative buffer size")
+		}
+		return ChanValue{}
+	}
+	if typ.ChanDir() != BothDir {
+		if willPrintDebug {
+			panic("reflect.MakeChan: unidirectional channel type")
+		}
+		return ChanValue{}
+	}
+	ch := makechan(typ, buffer)
+	return ChanValue{Value: Value{Type: typ, Ptr: ch, Flag: Flag(Chan)}}
+}
+
 // Copy copies the contents of src into dst until either
 // dst has been filled or src has been exhausted.
 // It returns the number of elements copied.
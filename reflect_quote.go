@@ -0,0 +1,50 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"unicode/utf8"
+)
+
+// Quote returns a double-quoted Go string literal representing s.
+// The returned string uses Go escape sequences (\t, \n, \xFF, \u0100) for control characters and non-printable
+// characters as defined by unicode.IsPrint. Bytes which are not valid UTF-8 are escaped as \x, so Unquote gives s back.
+func Quote(s string) string {
+	return string(appendQuotedWith(make([]byte, 0, 3*len(s)/2), s, '"', false))
+}
+
+// QuoteToASCII returns a double-quoted Go string literal representing s.
+// The returned string uses Go escape sequences (\t, \n, \xFF, \u0100) for non-ASCII characters and non-printable
+// characters as defined by unicode.IsPrint.
+func QuoteToASCII(s string) string {
+	return string(appendQuotedWith(make([]byte, 0, 3*len(s)/2), s, '"', true))
+}
+
+// QuoteRune returns a single-quoted Go character literal representing the rune.
+// The returned string uses Go escape sequences (\t, \n, \xFF, \u0100) for control characters and non-printable
+// characters as defined by unicode.IsPrint. An invalid rune is quoted as utf8.RuneError.
+func QuoteRune(r rune) string {
+	var buf [16]byte // enough for '\U0010ffff'
+	return string(appendQuotedRuneWith(buf[:0], r, '\'', false))
+}
+
+// AppendQuote appends a double-quoted Go string literal representing s, as generated by Quote, to dst
+// and returns the extended buffer. It does not allocate when dst has enough capacity.
+func AppendQuote(dst []byte, s string) []byte {
+	return appendQuotedWith(dst, s, '"', false)
+}
+
+// from strconv - appendQuotedRuneWith appends r to buf as a Go character literal delimited by quote.
+func appendQuotedRuneWith(buf []byte, r rune, quote byte, ASCIIonly bool) []byte {
+	buf = append(buf, quote)
+	if !utf8.ValidRune(r) {
+		r = utf8.RuneError
+	}
+	buf = appendEscapedRune(buf, r, quote, ASCIIonly)
+	buf = append(buf, quote)
+	return buf
+}
@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect_test
+
+import (
+	. "github.com/badu/reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// run with : go test -run XXX -fuzz FuzzQuote$
+func FuzzQuote(f *testing.F) {
+	for _, s := range quoteTests {
+		f.Add(s.in)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, quoted := range []string{Quote(s), QuoteToASCII(s), string(AppendQuote(nil, s))} {
+			if got, err := Unquote(quoted); err != nil || got != s {
+				t.Fatalf("Unquote(%s) : got %q, %v, want %q", quoted, got, err, s)
+			}
+		}
+	})
+}
+
+// run with : go test -run XXX -fuzz FuzzQuoteRune
+func FuzzQuoteRune(f *testing.F) {
+	for _, r := range []rune{'a', '\'', '\x7f', '☺', 0xFFFD, 0x10FFFF, 0x110000, -1} {
+		f.Add(r)
+	}
+	f.Fuzz(func(t *testing.T, r rune) {
+		want := r
+		if !utf8.ValidRune(r) {
+			want = utf8.RuneError
+		}
+		quoted := QuoteRune(r)
+		if got, err := Unquote(quoted); err != nil || got != string(want) {
+			t.Fatalf("Unquote(%s) : got %q, %v, want %q", quoted, got, err, string(want))
+		}
+	})
+}
@@ -32,6 +32,7 @@ func (t *RType) convToPtr() *ptrType         { return (*ptrType)(unsafe.Pointer(
 func (t *RType) convToStruct() *structType   { return (*structType)(unsafe.Pointer(t)) }
 func (t *RType) convToFn() *funcType         { return (*funcType)(unsafe.Pointer(t)) }
 func (t *RType) convToIface() *ifaceType     { return (*ifaceType)(unsafe.Pointer(t)) }
+func (t *RType) convToChan() *chanType       { return (*chanType)(unsafe.Pointer(t)) }
 func (t *RType) numIn() int                  { return int(t.convToFn().InLen) }
 func (t *RType) numOut() int                 { return len(t.convToFn().outParams()) }
 func (t *RType) ConvToMap() *mapType         { return (*mapType)(unsafe.Pointer(t)) }
@@ -98,6 +99,168 @@ func (t *RType) nomen() []byte {
 	return s
 }
 
+// NumMethod returns the number of exported methods in the type's method set.
+// For interface types, it returns the number of methods of the interface.
+func (t *RType) NumMethod() int {
+	if t.Kind() == Interface {
+		return t.NoOfIfaceMethods()
+	}
+	return lenExportedMethods(t)
+}
+
+// Method returns the i'th method in the type's method set, sorted by name.
+//
+// For a non-interface type T or *T, the returned Method's Type and Func
+// fields describe a function whose first argument is the receiver.
+//
+// For an interface type, the returned Method's Type field gives the
+// method signature, without a receiver, and the Func field is invalid.
+func (t *RType) Method(i int) Method {
+	if t.Kind() == Interface {
+		methods := t.ifaceMethods()
+		if uint(i) >= uint(len(methods)) {
+			if willPrintDebug {
+				panic("reflect.RType.Method: interface method index out of range")
+			}
+			return Method{}
+		}
+		methodName, pkgPath, methodType := t.ifaceMethod(i)
+		return Method{Name: string(methodName), PkgPath: string(pkgPath), Type: methodType, MethodType: methodType, Index: i}
+	}
+
+	methods := exportedMethods(t)
+	if uint(i) >= uint(len(methods)) {
+		if willPrintDebug {
+			panic("reflect.RType.Method: method index out of range")
+		}
+		return Method{}
+	}
+	p := methods[i]
+	methodType := t.typeOffset(p.typeOffset)
+	fnType := methodType.convToFn()
+
+	in := make([]*RType, 0, 1+int(fnType.InLen))
+	in = append(in, t)
+	in = append(in, fnType.inParams()...)
+	out := make([]*RType, 0, len(fnType.outParams()))
+	out = append(out, fnType.outParams()...)
+	withReceiver := FuncOf(in, out, fnType.isVariadic())
+
+	normCall := t.textOffset(p.normCall)
+	return Method{
+		Name:       string(t.nameOffset(p.nameOffset).name()),
+		Type:       withReceiver,
+		MethodType: methodType,
+		Func:       Value{Type: withReceiver, Ptr: unsafe.Pointer(&normCall), Flag: Flag(Func)},
+		Index:      i,
+	}
+}
+
+// MethodByName returns the method with that name in the type's method set
+// and a boolean indicating if the method was found.
+func (t *RType) MethodByName(name string) (Method, bool) {
+	if t.Kind() == Interface {
+		methods := t.ifaceMethods()
+		for i := range methods {
+			if string(t.nameOffset(methods[i].nameOffset).name()) == name {
+				return t.Method(i), true
+			}
+		}
+		return Method{}, false
+	}
+	methods := exportedMethods(t)
+	for i := range methods {
+		if string(t.nameOffset(methods[i].nameOffset).name()) == name {
+			return t.Method(i), true
+		}
+	}
+	return Method{}, false
+}
+
+// IfaceMethods calls inspect for every method of the interface type t, sorted by name.
+// Unexported methods are reported too, with their package path.
+func (t *RType) IfaceMethods(inspect IfaceMethodInspectFn) {
+	if t.Kind() != Interface {
+		if willPrintDebug {
+			panic("reflect.RType.IfaceMethods: " + TypeToString(t) + " is not an interface")
+		}
+		return
+	}
+	for i := range t.ifaceMethods() {
+		methodName, pkgPath, methodType := t.ifaceMethod(i)
+		inspect(methodName, pkgPath, methodType, i)
+	}
+}
+
+// MissingMethods returns the methods required by the interface type iface which are not in the method set of t.
+// It returns nil when t implements iface. A method is reported as missing when t has no method with that name,
+// or when t's method has a different signature : use MethodByName to tell these two cases apart.
+func (t *RType) MissingMethods(iface *RType) []Method {
+	if iface == nil || iface.Kind() != Interface {
+		if willPrintDebug {
+			panic("reflect.RType.MissingMethods: parameter is not an interface")
+		}
+		return nil
+	}
+	var missing []Method
+	for i := range iface.ifaceMethods() {
+		methodName, pkgPath, methodType := iface.ifaceMethod(i)
+		if !t.hasMethod(methodName, pkgPath, methodType) {
+			missing = append(missing, iface.Method(i))
+		}
+	}
+	return missing
+}
+
+// ifaceMethod returns the name, the package path (nil for exported methods) and the signature of the i'th method of an interface type.
+func (t *RType) ifaceMethod(i int) ([]byte, []byte, *RType) {
+	p := &t.ifaceMethods()[i]
+	methodName := t.nameOffset(p.nameOffset)
+	var pkgPath []byte
+	if !methodName.isExported() {
+		pkgPath = methodName.pkgPath()
+		if len(pkgPath) == 0 {
+			pkgPath = t.convToIface().pkgPath.name()
+		}
+	}
+	return methodName.name(), pkgPath, t.typeOffset(p.typeOffset)
+}
+
+// hasMethod reports whether the method set of t contains a method with the given name, package path and signature.
+func (t *RType) hasMethod(name, pkgPath []byte, signature *RType) bool {
+	if t.Kind() == Interface {
+		for i := range t.ifaceMethods() {
+			methodName, methodPkgPath, methodType := t.ifaceMethod(i)
+			if methodType == signature && bytes.Equal(methodName, name) && bytes.Equal(methodPkgPath, pkgPath) {
+				return true
+			}
+		}
+		return false
+	}
+
+	all, ok := methods(t)
+	if !ok {
+		return false
+	}
+	for i := range all {
+		methodName := t.nameOffset(all[i].nameOffset)
+		if !bytes.Equal(methodName.name(), name) || t.typeOffset(all[i].typeOffset) != signature {
+			continue
+		}
+		if methodName.isExported() {
+			return true
+		}
+		methodPkgPath := methodName.pkgPath()
+		if len(methodPkgPath) == 0 {
+			if pkg, ok := t.pkg(); ok {
+				methodPkgPath = t.nameOffset(pkg).name()
+			}
+		}
+		return bytes.Equal(methodPkgPath, pkgPath)
+	}
+	return false
+}
+
 // Implements reports whether the type implements the interface type u.
 // You always have to provide an Interface Kind of *Type
 // Of course, providing nil, returns false
@@ -122,6 +285,8 @@ func (t *RType) AssignableTo(u *RType) bool {
 
 // ConvertibleTo reports whether a value of the type is convertible to type u.
 // Of course, providing nil, returns false
+// Even if ConvertibleTo returns true, the conversion may still panic : a slice of type []T is convertible to *[N]T
+// and to [N]T, but the conversion panics if its length is less than N.
 func (t *RType) ConvertibleTo(u *RType) bool {
 	if u == nil {
 		return false
@@ -176,10 +341,22 @@ func (t *RType) directlyAssignable(dest *RType) bool {
 	if dest.hasName() && t.hasName() || dest.Kind() != t.Kind() {
 		return false
 	}
+	if dest.Kind() == Chan && t.specialChannelAssignability(dest) {
+		return true
+	}
 	// x's type T and V must  have identical underlying types.
 	return t.haveIdenticalUnderlyingType(dest, true)
 }
 
+// specialChannelAssignability reports whether a value x of channel type V can be directly assigned to a channel type T.
+// https://golang.org/doc/go_spec.html#Assignability
+// Special case: x is a bidirectional channel value, T is a channel type, at least one of T and V is unnamed,
+// and x's type V and T have identical element types.
+func (t *RType) specialChannelAssignability(dest *RType) bool {
+	return ChanDir(t.convToChan().dir) == BothDir && (!dest.hasName() || !t.hasName()) &&
+		t.convToChan().ElemType.haveIdenticalType(dest.convToChan().ElemType, true)
+}
+
 func (t *RType) haveIdenticalType(dest *RType, cmpTags bool) bool {
 	if cmpTags {
 		return dest == t
@@ -236,6 +413,10 @@ func (t *RType) haveIdenticalUnderlyingType(dest *RType, cmpTags bool) bool {
 		}
 		// Might have the same methods but still need a run time conversion.
 		return false
+	case Chan:
+		destChan := dest.convToChan()
+		srcChan := t.convToChan()
+		return srcChan.dir == destChan.dir && srcChan.ElemType.haveIdenticalType(destChan.ElemType, cmpTags)
 	case Map:
 		return t.ConvToMap().KeyType.haveIdenticalType(dest.ConvToMap().KeyType, cmpTags) && t.ConvToMap().ElemType.haveIdenticalType(dest.ConvToMap().ElemType, cmpTags)
 	case Slice:
@@ -274,27 +455,38 @@ func (t *RType) haveIdenticalUnderlyingType(dest *RType, cmpTags bool) bool {
 }
 
 func (t *RType) pkg() (int32, bool) {
-	if !t.hasInfoFlag() {
+	ut := t.uncommon()
+	if ut == nil {
 		return 0, false
 	}
-	var ut *uncommonType
+	return ut.pkgPath, true
+}
+
+// uncommon returns the uncommonType which follows t in memory, or nil if t has none.
+func (t *RType) uncommon() *uncommonType {
+	if !t.hasInfoFlag() {
+		return nil
+	}
 	switch t.Kind() {
 	case Struct:
-		ut = &(*uncommonStruct)(unsafe.Pointer(t)).u
+		return &(*uncommonStruct)(unsafe.Pointer(t)).u
 	case Ptr:
-		ut = &(*uncommonPtr)(unsafe.Pointer(t)).u
+		return &(*uncommonPtr)(unsafe.Pointer(t)).u
 	case Func:
-		ut = &(*uncommonFunc)(unsafe.Pointer(t)).u
+		return &(*uncommonFunc)(unsafe.Pointer(t)).u
 	case Slice:
-		ut = &(*uncommonSlice)(unsafe.Pointer(t)).u
+		return &(*uncommonSlice)(unsafe.Pointer(t)).u
 	case Array:
-		ut = &(*uncommonArray)(unsafe.Pointer(t)).u
+		return &(*uncommonArray)(unsafe.Pointer(t)).u
 	case Interface:
-		ut = &(*uncommonInterface)(unsafe.Pointer(t)).u
+		return &(*uncommonInterface)(unsafe.Pointer(t)).u
+	case Chan:
+		return &(*uncommonChan)(unsafe.Pointer(t)).u
+	case Map:
+		return &(*uncommonMap)(unsafe.Pointer(t)).u
 	default:
-		ut = &(*uncommonConcrete)(unsafe.Pointer(t)).u
+		return &(*uncommonConcrete)(unsafe.Pointer(t)).u
 	}
-	return ut.pkgPath, true
 }
 
 // implements reports whether the type V implements the interface type T.
@@ -440,6 +632,17 @@ func (t *RType) convertible(dst *RType) bool {
 				return true
 			}
 		}
+		// the slice and the array types have identical element types
+		if destKind == Ptr && dst.Deref().Kind() == Array && dst.Deref().ConvToArray().ElemType == sliceElem {
+			return true
+		}
+		if destKind == Array && dst.ConvToArray().ElemType == sliceElem {
+			return true
+		}
+	case Chan:
+		if destKind == Chan && t.specialChannelAssignability(dst) {
+			return true
+		}
 	}
 
 	// dst and src have same underlying type.
@@ -488,6 +691,29 @@ func (t *RType) Bits() int {
 	return int(t.size) * 8
 }
 
+// IsVariadic reports whether a function type's final input parameter is a "..." parameter.
+// If so, the last input parameter is actually a slice []T of the parameter's implicit type.
+func (t *RType) IsVariadic() bool {
+	if t.Kind() != Func {
+		if willPrintDebug {
+			panic("reflect.IsVariadic of non-func type")
+		}
+		return false
+	}
+	return t.convToFn().isVariadic()
+}
+
+// ChanDir returns a channel type's direction.
+func (t *RType) ChanDir() ChanDir {
+	if t.Kind() != Chan {
+		if willPrintDebug {
+			panic("reflect.ChanDir of non-chan type")
+		}
+		return 0
+	}
+	return ChanDir(t.convToChan().dir)
+}
+
 func (t *RType) addTypeBits(vec *bitVector, offset uintptr) {
 	switch t.Kind() {
 	case Chan, Func, Map, Ptr, Slice, String, UnsafePointer:
@@ -591,6 +817,191 @@ func (t *RType) Fields(inspect InspectTypeFn) {
 	}
 }
 
+// Field returns a struct type's i'th field.
+func (t *RType) Field(i int) StructField {
+	if t.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.RType.Field: Requested field of non-struct type")
+		}
+		return StructField{}
+	}
+	structType := t.convToStruct()
+	if uint(i) >= uint(len(structType.fields)) {
+		if willPrintDebug {
+			panic("reflect.RType.Field: Field index out of bounds")
+		}
+		return StructField{}
+	}
+	field := &structType.fields[i]
+	result := StructField{
+		Type:      field.Type,
+		Name:      string(field.name.name()),
+		Tag:       string(field.name.tag()),
+		Offset:    structFieldOffset(field),
+		Index:     []int{i},
+		Anonymous: isEmbedded(field),
+	}
+	if !field.name.isExported() {
+		result.PkgPath = string(structType.pkgPath.name())
+	}
+	return result
+}
+
+// FieldByIndex returns the nested field corresponding to index, walking through embedded pointers to structs.
+func (t *RType) FieldByIndex(index []int) StructField {
+	result := StructField{Type: t}
+	for i, x := range index {
+		if i > 0 {
+			if result.Type.Kind() == Ptr && result.Type.Deref().Kind() == Struct {
+				result.Type = result.Type.Deref()
+			}
+		}
+		result = result.Type.Field(x)
+	}
+	return result
+}
+
+// FieldByName returns the struct field with the given name, following Go's promotion rules for embedded fields,
+// and a boolean indicating if the field was found. The Index of the returned field is the path for FieldByIndex.
+func (t *RType) FieldByName(name string) (StructField, bool) {
+	if t.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.RType.FieldByName: Requested field of non-struct type")
+		}
+		return StructField{}, false
+	}
+	// Quick check for top-level name, or struct without embedded fields.
+	byteName := []byte(name)
+	structType := t.convToStruct()
+	hasEmbeds := false
+	if name != "" {
+		for i := range structType.fields {
+			field := &structType.fields[i]
+			if bytes.Equal(field.name.name(), byteName) {
+				return t.Field(i), true
+			}
+			if isEmbedded(field) {
+				hasEmbeds = true
+			}
+		}
+	}
+	if !hasEmbeds {
+		return StructField{}, false
+	}
+	return t.FieldByNameFunc(func(fieldName []byte) bool { return bytes.Equal(fieldName, byteName) })
+}
+
+// FieldByNameFunc returns the struct field with a name that satisfies the match function
+// and a boolean indicating if the field was found.
+//
+// FieldByNameFunc considers the fields in the struct itself and then the fields in any embedded structs,
+// in breadth first order, stopping at the shallowest nesting depth containing one or more fields satisfying
+// the match function. If multiple fields at that depth satisfy the match function, they cancel each other
+// and FieldByNameFunc returns no match. This behavior mirrors Go's handling of name lookup in
+// structs containing embedded fields.
+func (t *RType) FieldByNameFunc(match func(name []byte) bool) (StructField, bool) {
+	if t.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.RType.FieldByNameFunc: Requested field of non-struct type")
+		}
+		return StructField{}, false
+	}
+	// This uses the same condition that the Go language does: there must be a unique instance of the match at a given depth level.
+	// If there are multiple instances of a match at the same depth, they annihilate each other and inhibit any possible match at a lower level.
+	// The algorithm is breadth first search, one depth level at a time.
+
+	// The current and next slices are work queues:
+	// current lists the fields to visit on this depth level, and next lists the fields on the next lower level.
+	current := []fieldScan{}
+	next := []fieldScan{{typ: t.convToStruct()}}
+
+	// nextCount records the number of times an embedded type has been encountered and considered for queueing in the 'next' slice.
+	// We only queue the first one, but we increment the count on each.
+	// If a struct type T can be reached more than once at a given depth level, then it annihilates itself and need not be considered at all when we process that next depth level.
+	var nextCount map[*structType]int
+
+	// visited records the structs that have been considered already.
+	// Embedded pointer fields can create cycles in the graph of reachable embedded types; visited avoids following those cycles.
+	// It also avoids duplicated effort: if we didn't find the field in an embedded type T at level 2, we won't find it in one at level 4 either.
+	visited := map[*structType]bool{}
+
+	var (
+		result StructField
+		ok     bool
+	)
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count := nextCount
+		nextCount = nil
+
+		// Process all the fields at this depth, now listed in 'current'.
+		// The loop queues embedded fields found in 'next', for processing during the next iteration.
+		// The multiplicity of the 'current' field counts is recorded in 'count'; the multiplicity of the 'next' field counts is recorded in 'nextCount'.
+		for _, scan := range current {
+			scanned := scan.typ
+			if visited[scanned] {
+				// We've looked through this type before, at a higher level.
+				// That higher level would shadow the lower level we're now at, so this one can't be useful to us. Ignore it.
+				continue
+			}
+			visited[scanned] = true
+			for i := range scanned.fields {
+				field := &scanned.fields[i]
+				// Find name and (for embedded field) type for field f.
+				var embeddedType *RType
+				if isEmbedded(field) {
+					// Embedded field of type T or *T.
+					embeddedType = field.Type
+					if embeddedType.Kind() == Ptr {
+						embeddedType = embeddedType.Deref()
+					}
+				}
+
+				// Does it match?
+				if match(field.name.name()) {
+					// Potential match
+					if count[scanned] > 1 || ok {
+						// Name appeared multiple times at this level: annihilate.
+						return StructField{}, false
+					}
+					result = scanned.Field(i)
+					result.Index = nil
+					result.Index = append(result.Index, scan.index...)
+					result.Index = append(result.Index, i)
+					ok = true
+					continue
+				}
+
+				// Queue embedded struct fields for processing with next level,
+				// but only if we haven't seen a match yet at this level and only if the embedded types haven't already been queued.
+				if ok || embeddedType == nil || embeddedType.Kind() != Struct {
+					continue
+				}
+				embeddedStruct := embeddedType.convToStruct()
+				if nextCount[embeddedStruct] > 0 {
+					nextCount[embeddedStruct] = 2 // exact multiple doesn't matter
+					continue
+				}
+				if nextCount == nil {
+					nextCount = map[*structType]int{}
+				}
+				nextCount[embeddedStruct] = 1
+				if count[scanned] > 1 {
+					nextCount[embeddedStruct] = 2 // exact multiple doesn't matter
+				}
+				var index []int
+				index = append(index, scan.index...)
+				index = append(index, i)
+				next = append(next, fieldScan{typ: embeddedStruct, index: index})
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	return result, ok
+}
+
 func (t *RType) StructFields() []structField {
 	return t.convToStruct().fields
 }
@@ -0,0 +1,324 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"unsafe"
+)
+
+var (
+	omitEmptyOption = []byte("omitempty")
+)
+
+// StructToMap returns the exported fields of the struct v (or of the struct pointed by v) as a map.
+// Keys are the names found in the tagKey tag of each field (the field name when the tag has none) :
+// fields tagged "-" are skipped and fields with the omitempty option are skipped when empty, like encoding/json does.
+// Nested structs become nested maps, slices and arrays of structs become []interface{} holding maps and
+// embedded structs without a tag name have their fields promoted into the parent map.
+// Structs without exported fields (like time.Time) are kept as they are. v must not contain pointer cycles.
+func StructToMap(v Value, tagKey string) map[string]interface{} {
+	if v.Kind() == Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Deref()
+	}
+	if v.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.StructToMap: parameter is not a struct (or a pointer to a struct)")
+		}
+		return nil
+	}
+	result := MakeMap(TypeOf(map[string]interface{}(nil)))
+	structToMap(ToStruct(v), []byte(tagKey), result)
+	return result.Interface().(map[string]interface{})
+}
+
+// MapToStruct stores the entries of m into the fields of the struct dst, which must be settable (or a non nil pointer to a struct).
+// Field names are resolved like in StructToMap, entries without a field are ignored.
+// Values are assigned directly when possible, numeric values are converted when the field type can hold every
+// value of the entry's type (int32 into int64 or float64, uint8 into int16, float32 into float64 and so on).
+// Nested map[string]interface{} fill nested structs, []interface{} fill slices and nil pointers are allocated on the way.
+// The first entry which cannot be stored is reported as a *BindError, leaving the previous fields set.
+func MapToStruct(m map[string]interface{}, dst Value, tagKey string) error {
+	if !dst.IsValid() {
+		return ErrInvalidValue
+	}
+	if dst.Kind() == Ptr {
+		if dst.IsNil() {
+			return ErrNilPointer
+		}
+		dst = dst.Deref()
+	}
+	if dst.Kind() != Struct || !dst.CanSet() {
+		return ErrNotAssignable
+	}
+	return mapToStruct(m, ToStruct(dst), []byte(tagKey), dst.Type.String())
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	return "reflect.MapToStruct: " + e.Path + ": " + e.Err.Error()
+}
+
+// bindingName returns the name of a field as a map key, whether the tag had a name, and the omitempty and skip options.
+func bindingName(name, tag, tagKey []byte) ([]byte, bool, bool, bool) {
+	if len(tagKey) == 0 || len(tag) == 0 {
+		return name, false, false, false
+	}
+	found, ok := ParseTag(tag).Lookup(tagKey)
+	if !ok {
+		return name, false, false, false
+	}
+	if string(found.Value) == "-" {
+		return nil, false, false, true
+	}
+	omitEmpty := found.HasOption(omitEmptyOption)
+	if tagName := found.Name(); len(tagName) > 0 {
+		return tagName, true, omitEmpty, false
+	}
+	return name, false, omitEmpty, false
+}
+
+// embeddedStruct returns the struct held by an embedded field, following a pointer.
+func embeddedStruct(field Value) (Value, bool) {
+	if field.Kind() == Ptr {
+		if field.IsNil() || field.Type.Deref().Kind() != Struct {
+			return Value{}, false
+		}
+		field = field.Deref()
+	}
+	return field, field.Kind() == Struct
+}
+
+func structToMap(v StructValue, tagKey []byte, dst MapValue) {
+	v.Fields(func(typ *RType, name []byte, tag []byte, pack []byte, embedded, exported bool, offset uintptr, index int, _ unsafe.Pointer) {
+		key, named, omitEmpty, skip := bindingName(name, tag, tagKey)
+		if skip {
+			return
+		}
+		field := v.Field(index)
+		if embedded && !named {
+			// promote the fields of the embedded struct, even if the embedded type itself is unexported
+			if inner, ok := embeddedStruct(field); ok {
+				structToMap(ToStruct(inner), tagKey, dst)
+				return
+			}
+		}
+		if !exported || omitEmpty && isEmptyValue(field) {
+			return
+		}
+		elem := toMapValue(field, tagKey)
+		if elem == nil {
+			dst.SetMapIndex(ReflectOn(string(key)), Zero(dst.Type.ConvToMap().ElemType))
+			return
+		}
+		dst.SetMapIndex(ReflectOn(string(key)), ReflectOn(elem))
+	})
+}
+
+// toMapValue converts the field v for StructToMap : structs to maps and slices of structs to slices of maps.
+func toMapValue(v Value, tagKey []byte) interface{} {
+	switch v.Kind() {
+	case Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if deref := v.Type.Deref(); deref.Kind() == Struct && hasExportedFields(deref) {
+			return toMapValue(v.Deref(), tagKey)
+		}
+	case Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toMapValue(v.Iface(), tagKey)
+	case Struct:
+		if hasExportedFields(v.Type) {
+			nested := MakeMap(TypeOf(map[string]interface{}(nil)))
+			structToMap(ToStruct(v), tagKey, nested)
+			return nested.Interface()
+		}
+	case Slice, Array:
+		if v.Kind() == Slice && v.IsNil() {
+			break
+		}
+		elem := v.Type.ConvToSlice().ElemType
+		if v.Kind() == Array {
+			elem = v.Type.ConvToArray().ElemType
+		}
+		if elem.Kind() == Ptr {
+			elem = elem.Deref()
+		}
+		if elem.Kind() != Struct || !hasExportedFields(elem) {
+			break
+		}
+		items := ToSlice(v)
+		result := make([]interface{}, items.Len())
+		for i := range result {
+			result[i] = toMapValue(items.Index(i), tagKey)
+		}
+		return result
+	}
+	return v.Interface()
+}
+
+func hasExportedFields(t *RType) bool {
+	structType := t.convToStruct()
+	for i := range structType.fields {
+		if structType.fields[i].name.isExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether v is empty in the omitempty sense : false, 0, a nil pointer, a nil interface value
+// and any empty array, slice, map, or string. Structs are never empty.
+func isEmptyValue(v Value) bool {
+	switch v.Kind() {
+	case Array, Slice, String:
+		return ToSlice(v).Len() == 0
+	case Map:
+		return ToMap(v).Len() == 0
+	case Bool:
+		return !v.Bool().Get()
+	case Int, Int8, Int16, Int32, Int64:
+		return v.Int().Get() == 0
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		return v.Uint().Get() == 0
+	case Float32, Float64:
+		return v.Float().Get() == 0
+	case Interface, Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func mapToStruct(m map[string]interface{}, v StructValue, tagKey []byte, path string) error {
+	structType := v.Type.convToStruct()
+	for i := range structType.fields {
+		field := &structType.fields[i]
+		key, named, _, skip := bindingName(field.name.name(), field.name.tag(), tagKey)
+		if skip {
+			continue
+		}
+		fieldValue := v.Field(i)
+		if isEmbedded(field) && !named {
+			if fieldValue.Kind() == Ptr && fieldValue.IsNil() && fieldValue.Type.Deref().Kind() == Struct {
+				if !fieldValue.CanSet() {
+					// unexported embedded pointer : cannot allocate it
+					continue
+				}
+				fieldValue.Set(New(fieldValue.Type.Deref()))
+			}
+			if inner, ok := embeddedStruct(fieldValue); ok {
+				if err := mapToStruct(m, ToStruct(inner), tagKey, path+"."+string(field.name.name())); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.name.isExported() {
+			continue
+		}
+		raw, ok := m[string(key)]
+		if !ok {
+			continue
+		}
+		if err := bindValue(fieldValue, raw, tagKey, path+"."+string(field.name.name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindValue stores raw into the settable dst, recursing into structs, slices, maps and pointers.
+func bindValue(dst Value, raw interface{}, tagKey []byte, path string) error {
+	if raw == nil {
+		dst.Set(Zero(dst.Type))
+		return nil
+	}
+	src := ReflectOn(raw)
+	if src.Type.AssignableTo(dst.Type) {
+		dst.Set(src)
+		return nil
+	}
+	switch dst.Kind() {
+	case Ptr:
+		elem := New(dst.Type.Deref())
+		if err := bindValue(elem.Deref(), raw, tagKey, path); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case Struct:
+		if nested, ok := raw.(map[string]interface{}); ok {
+			return mapToStruct(nested, ToStruct(dst), tagKey, path)
+		}
+	case Slice:
+		if items, ok := raw.([]interface{}); ok {
+			slice := MakeSlice(dst.Type, len(items), len(items))
+			for i, item := range items {
+				if err := bindValue(slice.Index(i), item, tagKey, path+"["+I2A(i, -1)+"]"); err != nil {
+					return err
+				}
+			}
+			dst.Set(slice.Value)
+			return nil
+		}
+	case Map:
+		if entries, ok := raw.(map[string]interface{}); ok && dst.Type.ConvToMap().KeyType.Kind() == String {
+			result := MakeMapWithSize(dst.Type, len(entries))
+			for key, entry := range entries {
+				elem := New(dst.Type.ConvToMap().ElemType).Deref()
+				if err := bindValue(elem, entry, tagKey, path+"["+key+"]"); err != nil {
+					return err
+				}
+				result.SetMapIndex(Convert(ReflectOn(key), dst.Type.ConvToMap().KeyType), elem)
+			}
+			dst.Set(result.Value)
+			return nil
+		}
+	}
+	if canWiden(src.Type, dst.Type) {
+		dst.Set(Convert(src, dst.Type))
+		return nil
+	}
+	return &BindError{Path: path, Err: ErrNotConvertible}
+}
+
+// canWiden reports whether every value of the numeric type from is exactly represented by the numeric type to.
+func canWiden(from, to *RType) bool {
+	fromBits, toBits := int(from.size)*8, int(to.size)*8
+	switch to.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		switch from.Kind() {
+		case Int, Int8, Int16, Int32, Int64:
+			return toBits >= fromBits
+		case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+			return toBits > fromBits
+		}
+	case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+		switch from.Kind() {
+		case Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+			return toBits >= fromBits
+		}
+	case Float32, Float64:
+		// integers are exact up to the size of the mantissa
+		mantissa := 24
+		if to.Kind() == Float64 {
+			mantissa = 53
+		}
+		switch from.Kind() {
+		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, UintPtr:
+			return fromBits <= mantissa
+		case Float32, Float64:
+			return toBits >= fromBits
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+// PlanOf returns the plan of the struct type t (or of the struct pointed by t).
+// The plan is built on first use and cached, so it's safe to call PlanOf from concurrent goroutines.
+func PlanOf(t *RType) *StructPlan {
+	if t != nil && t.Kind() == Ptr {
+		t = t.Deref()
+	}
+	if t == nil || t.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.PlanOf: parameter is not a struct type")
+		}
+		return nil
+	}
+	if cached, ok := planCache.Load(t); ok {
+		return cached.(*StructPlan)
+	}
+	plan := &StructPlan{Type: t, byName: make(map[string]int)}
+	plan.flatten(t, nil, 0, 0, false, map[*RType]bool{t: true})
+	for i := range plan.Fields {
+		field := &plan.Fields[i]
+		name := string(field.Name)
+		if _, done := plan.byName[name]; done {
+			continue
+		}
+		// index the field which wins by Go's promotion rules (ambiguous names are not indexed)
+		promoted, ok := t.FieldByName(name)
+		if !ok {
+			continue
+		}
+		for j := range plan.Fields {
+			if sameIndex(plan.Fields[j].Index, promoted.Index) {
+				plan.byName[name] = j
+				break
+			}
+		}
+	}
+	actual, _ := planCache.LoadOrStore(t, plan)
+	return actual.(*StructPlan)
+}
+
+// flatten appends the fields of the struct type t, recursing into embedded structs (and pointers to structs).
+// visited guards against embedded pointer cycles.
+func (p *StructPlan) flatten(t *RType, index []int, offset uintptr, depth int, indirect bool, visited map[*RType]bool) {
+	structType := t.convToStruct()
+	for i := range structType.fields {
+		field := &structType.fields[i]
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		planField := PlanField{
+			Name:     field.name.name(),
+			Tag:      field.name.tag(),
+			Type:     field.Type,
+			Offset:   offset + structFieldOffset(field),
+			Index:    fieldIndex,
+			Depth:    depth,
+			Embedded: isEmbedded(field),
+			Exported: field.name.isExported(),
+			Indirect: indirect,
+		}
+		planField.Tags = splitTag(planField.Tag)
+		if !planField.Exported {
+			planField.PkgPath = structType.pkgPath.name()
+			if planField.Embedded {
+				planField.roFlag = embedROFlag
+			} else {
+				planField.roFlag = stickyROFlag
+			}
+		}
+		p.Fields = append(p.Fields, planField)
+
+		if !planField.Embedded {
+			continue
+		}
+		embedded, embeddedIndirect := field.Type, indirect
+		if embedded.Kind() == Ptr {
+			embedded, embeddedIndirect = embedded.Deref(), true
+		}
+		if embedded.Kind() != Struct || visited[embedded] {
+			continue
+		}
+		visited[embedded] = true
+		p.flatten(embedded, fieldIndex, offset+structFieldOffset(field), depth+1, embeddedIndirect, visited)
+		delete(visited, embedded)
+	}
+}
+
+// NumField returns the number of flattened fields.
+func (p *StructPlan) NumField() int {
+	return len(p.Fields)
+}
+
+// Lookup returns the field with the given name, following Go's promotion rules for embedded fields.
+// It does not allocate.
+func (p *StructPlan) Lookup(name []byte) (*PlanField, bool) {
+	i, ok := p.byName[string(name)]
+	if !ok {
+		return nil, false
+	}
+	return &p.Fields[i], true
+}
+
+// Value returns the field f of the struct value v, which must be of the plan's type.
+// Fields which are not reached through embedded pointers are accessed directly by offset.
+func (f *PlanField) Value(v StructValue) Value {
+	if f.Indirect {
+		return v.FieldByIndex(f.Index)
+	}
+	// Inherit permission bits from v, but clear embedROFlag.
+	fl := v.Flag&(stickyROFlag|pointerFlag|addressableFlag) | Flag(f.Type.Kind()) | f.roFlag
+	return Value{Type: f.Type, Ptr: add(v.Ptr, f.Offset), Flag: fl}
+}
+
+// TagNamed returns the unquoted value for key in the field's tag.
+func (f *PlanField) TagNamed(key []byte) ([]byte, bool) {
+	for i := range f.Tags {
+		if string(f.Tags[i].Key) == string(key) {
+			return f.Tags[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// splitTag splits a conventional tag into its key / unquoted value pairs, stopping at the first malformed pair (like TagLookup).
+func splitTag(tag []byte) []TagPair {
+	var result []TagPair
+	parser := ParseTag(tag)
+	for parser.Next() {
+		value := parser.Tag.Value
+		if parser.Tag.HasEscapes() {
+			unquoted, err := Unquote(`"` + string(value) + `"`)
+			if err != nil {
+				break
+			}
+			value = []byte(unquoted)
+		}
+		result = append(result, TagPair{Key: parser.Tag.Key, Value: value})
+	}
+	return result
+}
+
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
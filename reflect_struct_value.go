@@ -206,6 +206,7 @@ func (v StructValue) Field(i int) Value {
 		if willPrintDebug {
 			panic("reflect.Value.Field: Field index out of range")
 		}
+		return Value{}
 	}
 
 	field := &structType.fields[i]
@@ -241,6 +242,7 @@ func (v StructValue) FieldByIndex(index []int) Value {
 					if willPrintDebug {
 						panic("reflect.Value.FieldByIndex: indirection through nil pointer to embedded struct")
 					}
+					return Value{}
 				}
 				deref := v.Type.Deref()
 				if deref.Kind() == Struct {
@@ -253,17 +255,60 @@ func (v StructValue) FieldByIndex(index []int) Value {
 	return v.Value
 }
 
-// FieldByName returns the struct field with the given name.
-// It returns the zero Value if no field was found.
+// TryField returns the i'th field of the struct v, or ErrOutOfRange if there is no such field.
+func (v StructValue) TryField(i int) (Value, error) {
+	if !v.IsValid() {
+		return Value{}, ErrInvalidValue
+	}
+	if uint(i) >= uint(len(v.Type.convToStruct().fields)) {
+		return Value{}, ErrOutOfRange
+	}
+	return v.Field(i), nil
+}
+
+// TryFieldByIndex returns the nested field corresponding to index, like FieldByIndex,
+// but reports ErrOutOfRange for a bad index and ErrNilPointer when walking through a nil embedded pointer.
+func (v StructValue) TryFieldByIndex(index []int) (Value, error) {
+	if !v.IsValid() {
+		return Value{}, ErrInvalidValue
+	}
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == Ptr {
+				if v.IsNil() {
+					return Value{}, ErrNilPointer
+				}
+				v.Value = v.Deref()
+			}
+			if v.Kind() != Struct {
+				return Value{}, ErrOutOfRange
+			}
+		}
+		field, err := v.TryField(x)
+		if err != nil {
+			return Value{}, err
+		}
+		v.Value = field
+	}
+	return v.Value, nil
+}
+
+// FieldByName returns the struct field with the given name, including fields promoted from embedded structs.
+// It returns the zero Value if no field was found, or if the name is ambiguous at the shallowest depth it appears.
 func (v StructValue) FieldByName(name string) Value {
 	// we're sure that it is a struct : check is performed in ToStruct()
-	byteName := []byte(name)
-	structType := v.Type.convToStruct()
-	for i := range structType.fields {
-		field := &structType.fields[i]
-		if bytes.Equal(field.name.name(), byteName) {
-			return v.Field(i)
-		}
+	if field, ok := v.Type.FieldByName(name); ok {
+		return v.FieldByIndex(field.Index)
+	}
+	return Value{}
+}
+
+// FieldByNameFunc returns the struct field with a name that satisfies the match function.
+// It returns the zero Value if no field was found. See RType.FieldByNameFunc for the promotion rules.
+func (v StructValue) FieldByNameFunc(match func(name []byte) bool) Value {
+	// we're sure that it is a struct : check is performed in ToStruct()
+	if field, ok := v.Type.FieldByNameFunc(match); ok {
+		return v.FieldByIndex(field.Index)
 	}
 	return Value{}
 }
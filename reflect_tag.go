@@ -0,0 +1,384 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import "bytes"
+
+// ParseTag returns a parser over the `key:"value"` pairs of tag, as handed to InspectTypeFn and InspectValueFn.
+// Neither the parser, nor the pairs it produces allocate : they are all slices of tag.
+//
+// Example:
+//
+//	parser := ParseTag(tag)
+//	for parser.Next() {
+//		if string(parser.Tag.Key) == "json" && parser.Tag.HasOption([]byte("omitempty")) {
+//			...
+//		}
+//	}
+//	if parser.Err() != nil {
+//		...
+//	}
+//
+func ParseTag(tag []byte) TagParser {
+	return TagParser{tag: tag}
+}
+
+// Next advances to the next pair of the tag. It returns false at the end of the tag or on a syntax error.
+// It follows the same rules as TagLookup (which mirrors cmd/vet/structtag.go).
+func (p *TagParser) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	tag := p.tag[p.pos:]
+	// Skip leading space.
+	i := 0
+	for i < len(tag) && tag[i] == ' ' {
+		i++
+	}
+	p.pos += i
+	tag = tag[i:]
+	p.offset = p.pos
+	if len(tag) == 0 {
+		p.Tag = Tag{}
+		return false
+	}
+
+	// Scan to colon. A space, a quote or a control character is a syntax error.
+	i = 0
+	for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+		i++
+	}
+	if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+		p.offset = p.pos + i
+		return p.fail()
+	}
+	key := tag[:i]
+
+	// Scan quoted string to find value.
+	j := i + 2
+	for j < len(tag) && tag[j] != '"' {
+		if tag[j] == '\\' {
+			j++
+		}
+		j++
+	}
+	if j >= len(tag) {
+		p.offset = p.pos + i + 1
+		return p.fail()
+	}
+	p.Tag = Tag{Key: key, Value: tag[i+2 : j]}
+	p.pos += j + 1
+	return true
+}
+
+func (p *TagParser) fail() bool {
+	p.err = ErrSyntax
+	p.Tag = Tag{}
+	return false
+}
+
+// Err returns ErrSyntax if the parser stopped on a malformed pair, nil otherwise.
+func (p *TagParser) Err() error {
+	return p.err
+}
+
+// Offset returns the byte offset, inside the tag, of the current pair (or of the syntax error, after Next returned false).
+func (p *TagParser) Offset() int {
+	return p.offset
+}
+
+// Lookup returns the pair with the given key. It does not change the state of p.
+func (p TagParser) Lookup(key []byte) (Tag, bool) {
+	parser := ParseTag(p.tag)
+	for parser.Next() {
+		if bytes.Equal(parser.Tag.Key, key) {
+			return parser.Tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// HasEscapes reports whether the raw value contains backslash escapes, in which case it has to be unquoted with Unquote.
+func (t Tag) HasEscapes() bool {
+	return bytes.IndexByte(t.Value, '\\') >= 0
+}
+
+// Name returns the json style name : the part of the value before the first comma.
+func (t Tag) Name() []byte {
+	if i := bytes.IndexByte(t.Value, ','); i >= 0 {
+		return t.Value[:i]
+	}
+	return t.Value
+}
+
+// Options returns an iterator over the json style options : the comma separated parts of the value after the name.
+func (t Tag) Options() TagOptions {
+	i := bytes.IndexByte(t.Value, ',')
+	if i < 0 {
+		return TagOptions{sep: ','}
+	}
+	return TagOptions{rest: t.Value[i+1:], sep: ','}
+}
+
+// HasOption reports whether the json style options contain option.
+func (t Tag) HasOption(option []byte) bool {
+	options := t.Options()
+	for options.Next() {
+		if bytes.Equal(options.Option(), option) {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings returns an iterator over the sql style settings : the semicolon separated parts of the whole value,
+// like `type:TEXT NULL` and `NOT NULL` in `sql:"type:TEXT NULL;NOT NULL"`.
+func (t Tag) Settings() TagOptions {
+	return TagOptions{rest: t.Value, sep: ';'}
+}
+
+// Setting returns the value of the sql style setting with the given key (compared case insensitive).
+// Settings without a colon, like `NOT NULL`, are found with an empty value.
+func (t Tag) Setting(key []byte) ([]byte, bool) {
+	settings := t.Settings()
+	for settings.Next() {
+		settingKey, value := settings.KeyValue()
+		if bytes.EqualFold(settingKey, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// Next advances to the next option, skipping empty ones. It returns false when there are no more options.
+func (o *TagOptions) Next() bool {
+	for len(o.rest) > 0 {
+		i := bytes.IndexByte(o.rest, o.sep)
+		if i < 0 {
+			o.current, o.rest = o.rest, nil
+		} else {
+			o.current, o.rest = o.rest[:i], o.rest[i+1:]
+		}
+		o.current = bytes.TrimSpace(o.current)
+		if len(o.current) > 0 {
+			return true
+		}
+	}
+	o.current = nil
+	return false
+}
+
+// Option returns the current option.
+func (o *TagOptions) Option() []byte {
+	return o.current
+}
+
+// KeyValue splits the current option at the first colon : `type:TEXT NULL` gives `type` and `TEXT NULL`.
+// If there is no colon, the whole option is returned as key.
+func (o *TagOptions) KeyValue() ([]byte, []byte) {
+	if i := bytes.IndexByte(o.current, ':'); i >= 0 {
+		return bytes.TrimSpace(o.current[:i]), bytes.TrimSpace(o.current[i+1:])
+	}
+	return o.current, nil
+}
+
+// Error implements the error interface.
+func (e TagError) Error() string {
+	return e.Path + ": struct field tag `" + e.Tag + "` " + e.Reason + " (offset " + I2A(e.Offset, -1) + ")"
+}
+
+// ValidateTags checks the tags of every field of the struct type t (or of the struct pointed by t), recursively into embedded structs.
+// It reports the problems cmd/vet would report : malformed pairs and unbalanced quotes, pairs not separated by spaces,
+// spaces inside json, xml and asn1 values, duplicate keys inside a tag and duplicate json names among siblings
+// (fields promoted from embedded structs without a json name included).
+func ValidateTags(t *RType) []TagError {
+	if t != nil && t.Kind() == Ptr {
+		t = t.Deref()
+	}
+	if t == nil || t.Kind() != Struct {
+		if willPrintDebug {
+			panic("reflect.ValidateTags: parameter is not a struct type")
+		}
+		return nil
+	}
+	path := t.Name()
+	if path == "" {
+		path = "struct"
+	}
+	var errs []TagError
+	validateStructTags(t, path, true, map[*RType]bool{t: true}, &errs)
+	return errs
+}
+
+func validateStructTags(t *RType, path string, checkDuplicates bool, visited map[*RType]bool, errs *[]TagError) {
+	if checkDuplicates {
+		collectJSONNames(t, path, 0, make(map[jsonNameKey]string), map[*RType]bool{t: true}, errs)
+	}
+	structType := t.convToStruct()
+	for i := range structType.fields {
+		field := &structType.fields[i]
+		fieldPath := path + "." + string(field.name.name())
+		tag := field.name.tag()
+		validateTag(fieldPath, tag, errs)
+
+		if !isEmbedded(field) {
+			continue
+		}
+		embedded := field.Type
+		if embedded.Kind() == Ptr {
+			embedded = embedded.Deref()
+		}
+		if embedded.Kind() != Struct || visited[embedded] {
+			continue
+		}
+		visited[embedded] = true
+		// an embedded struct with a json name is not flattened by json : its fields are checked on their own
+		_, hasName := jsonName(tag)
+		validateStructTags(embedded, fieldPath, hasName, visited, errs)
+		delete(visited, embedded)
+	}
+}
+
+// validateTag mirrors validateStructTag from cmd/vet/structtag.go, reporting every problem it can recover from.
+func validateTag(path string, tag []byte, errs *[]TagError) {
+	report := func(offset int, reason string) {
+		*errs = append(*errs, TagError{Path: path, Tag: string(tag), Offset: offset, Reason: reason})
+	}
+	parser := ParseTag(tag)
+	end := 0
+	for n := 0; parser.Next(); n++ {
+		pair := parser.Tag
+		offset := parser.Offset()
+		if n > 0 && offset == end {
+			// More restrictive than reflect, but catches likely mistakes
+			// like `x:"foo",y:"bar"`, which parses as `x:"foo" ,y:"bar"` with second key ",y".
+			report(offset, "key:\"value\" pairs not separated by spaces")
+		}
+		end = parser.pos
+
+		duplicate := ParseTag(tag[:offset])
+		for duplicate.Next() {
+			if bytes.Equal(duplicate.Tag.Key, pair.Key) {
+				report(offset, "duplicate key \""+string(pair.Key)+"\"")
+				break
+			}
+		}
+
+		if pair.HasEscapes() {
+			if _, err := Unquote("\"" + string(pair.Value) + "\""); err != nil {
+				report(offset+len(pair.Key)+1, "bad syntax for struct tag value")
+				continue
+			}
+		}
+		value := pair.Value
+		valueOffset := offset + len(pair.Key) + 2
+		switch string(pair.Key) {
+		case "xml":
+			// If the first or last character in the XML tag is a space, it is suspicious.
+			// If there are multiple spaces, they are suspicious.
+			if len(value) > 0 && (value[0] == ' ' || value[len(value)-1] == ' ') || bytes.Count(value, []byte{' '}) > 1 {
+				report(valueOffset+bytes.IndexByte(value, ' '), "suspicious space in struct tag value")
+				continue
+			}
+			// If there is no comma, skip the rest of the checks.
+			comma := bytes.IndexByte(value, ',')
+			if comma < 0 {
+				continue
+			}
+			// If the character before a comma is a space, this is suspicious.
+			if comma > 0 && value[comma-1] == ' ' {
+				report(valueOffset+comma-1, "suspicious space in struct tag value")
+				continue
+			}
+			value = value[comma+1:]
+		case "json":
+			// JSON allows using spaces in the name, so skip it.
+			comma := bytes.IndexByte(value, ',')
+			if comma < 0 {
+				continue
+			}
+			value = value[comma+1:]
+		case "asn1":
+		default:
+			continue
+		}
+		if i := bytes.IndexByte(value, ' '); i >= 0 {
+			report(valueOffset+len(pair.Value)-len(value)+i, "suspicious space in struct tag value")
+		}
+	}
+	if parser.Err() != nil {
+		offset := parser.Offset()
+		switch {
+		case offset == parser.pos:
+			report(offset, "bad syntax for struct tag key")
+		case offset < len(tag) && (tag[offset] == ':' || tag[offset] == '"'):
+			report(offset, "bad syntax for struct tag value")
+		default:
+			report(offset, "bad syntax for struct tag pair")
+		}
+	}
+}
+
+// collectJSONNames reports the fields having the same json name at the same depth, descending into embedded structs which have no json name.
+func collectJSONNames(t *RType, path string, depth int, seen map[jsonNameKey]string, visited map[*RType]bool, errs *[]TagError) {
+	structType := t.convToStruct()
+	for i := range structType.fields {
+		field := &structType.fields[i]
+		fieldPath := path + "." + string(field.name.name())
+		tag := field.name.tag()
+		name, hasName := jsonName(tag)
+		if !hasName {
+			if !isEmbedded(field) {
+				continue
+			}
+			embedded := field.Type
+			if embedded.Kind() == Ptr {
+				embedded = embedded.Deref()
+			}
+			if embedded.Kind() == Struct && !visited[embedded] {
+				visited[embedded] = true
+				collectJSONNames(embedded, fieldPath, depth+1, seen, visited, errs)
+				delete(visited, embedded)
+			}
+			continue
+		}
+		if string(name) == "-" {
+			// ignored by json
+			continue
+		}
+		key := jsonNameKey{name: string(name), depth: depth}
+		if first, ok := seen[key]; ok {
+			*errs = append(*errs, TagError{Path: fieldPath, Tag: string(tag), Offset: jsonValueOffset(tag), Reason: "json tag \"" + key.name + "\" also at " + first})
+			continue
+		}
+		seen[key] = fieldPath
+	}
+}
+
+// jsonName returns the json name of a tag, if it has one (the "-" name, meaning the field is ignored, is returned as well).
+func jsonName(tag []byte) ([]byte, bool) {
+	json, ok := ParseTag(tag).Lookup([]byte("json"))
+	if !ok {
+		return nil, false
+	}
+	name := json.Name()
+	if len(name) == 0 {
+		return nil, false
+	}
+	return name, true
+}
+
+// jsonValueOffset returns the offset of the json value inside tag.
+func jsonValueOffset(tag []byte) int {
+	parser := ParseTag(tag)
+	for parser.Next() {
+		if string(parser.Tag.Key) == "json" {
+			return parser.Offset() + len(parser.Tag.Key) + 2
+		}
+	}
+	return 0
+}
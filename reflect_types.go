@@ -9,6 +9,7 @@ package reflect
 import (
 	"errors"
 	"runtime"
+	"sync"
 	"unsafe"
 
 	systemReflect "reflect"
@@ -86,10 +87,19 @@ const (
 	sqClosPar byte = ']'
 	star      byte = '*'
 
-	mapStr    = "map"
-	bucketStr = "bucket"
-	methStr   = "methodargs"
-	fnStr     = "funcargs"
+	mapStr      = "map"
+	chanStr     = "chan "
+	recvChanStr = "<-chan "
+	sendChanStr = "chan<- "
+	bucketStr   = "bucket"
+	methStr     = "methodargs"
+	fnStr       = "funcargs"
+	structStr   = "struct {"
+
+	lowerHex = "0123456789abcdef"
+
+	hashPrime64     = 1099511628211 // FNV-1 64 bit prime, used by Hash
+	hashCycleMarker = 2             // mixed by Hash in place of the values from which a cycle is reachable
 )
 
 const (
@@ -122,6 +132,37 @@ const (
 	UnsafePointer             // 26
 )
 
+const (
+	RecvDir ChanDir             = 1 << iota // <-chan
+	SendDir                                 // chan<-
+	BothDir = RecvDir | SendDir             // chan
+)
+
+const (
+	_             SelectDir = iota
+	SelectSend              // case Chan <- Send
+	SelectRecv              // case <-Chan:
+	SelectDefault           // default
+)
+
+const (
+	DiffChanged DiffOp = iota // the value differs on the two sides
+	DiffAdded                 // the element, map entry or pointed value exists only on the new side
+	DiffRemoved               // the element, map entry or pointed value exists only on the old side
+)
+
+const (
+	WalkContinue WalkAction = iota // walk through the children of the node
+	SkipChildren                   // don't walk through the children of the node (returned by Enter)
+	Stop                           // end the walk
+)
+
+const (
+	CloneShallowFuncs CloneOption = 1 << iota // funcs are shared with the original, instead of being left nil
+	CloneShallowChans                         // channels are shared with the original, instead of being left nil
+	CloneUnexported                           // unexported fields are copied as they are, instead of being left zero
+)
+
 var (
 	uint8Type *RType
 	kindNames = []string{
@@ -154,6 +195,36 @@ var (
 		UnsafePointer: "unsafe.Pointer",
 	}
 	ErrSyntax = errors.New("invalid syntax")
+	ErrRange  = errors.New("value out of range")
+
+	// Errors returned by the Try* family (TrySet, TryConvert, TryField, TrySetMapIndex), which never print nor panic.
+	ErrInvalidValue   = errors.New("reflect: invalid (zero) Value")
+	ErrUnexported     = errors.New("reflect: value obtained using unexported field")
+	ErrNotAssignable  = errors.New("reflect: value is not assignable")
+	ErrNotConvertible = errors.New("reflect: value is not convertible")
+	ErrOutOfRange     = errors.New("reflect: index out of range")
+	ErrNilPointer     = errors.New("reflect: indirection through nil pointer")
+
+	// funcLookupCache caches FuncOf lookups, so the same signature always yields the same *RType.
+	funcLookupCache struct {
+		sync.Mutex // Guards stores (but not loads) on m.
+
+		// m is a map[uint32][]*RType keyed by the hash calculated in FuncOf.
+		// Elements of m are append-only and thus safe for concurrent reading.
+		m sync.Map
+	}
+
+	// planCache is a map[*RType]*StructPlan holding the plans built by PlanOf.
+	planCache sync.Map
+
+	// structLookupCache caches StructOf lookups, so the same field list always yields the same *RType.
+	structLookupCache struct {
+		sync.Mutex // Guards stores (but not loads) on m.
+
+		// m is a map[uint32][]*RType keyed by the hash calculated in StructOf.
+		// Elements of m are append-only and thus safe for concurrent reading.
+		m sync.Map
+	}
 )
 
 type (
@@ -170,6 +241,52 @@ type (
 	// A Kind represents the specific kind of type that a Type represents. The zero Kind is not a valid kind.
 	Kind = uint
 
+	// ChanDir represents a channel type's direction.
+	ChanDir int
+
+	// A SelectDir describes the communication direction of a select case.
+	SelectDir int
+
+	// A DiffOp tells how a value differs between the two sides given to Diff.
+	DiffOp uint8
+
+	// A WalkAction tells Walk how to go on after a visitor callback.
+	WalkAction uint8
+
+	// A Visitor is called by Walk when entering a node, before its children, and when leaving it, after its children.
+	Visitor interface {
+		Enter(node *WalkNode) WalkAction
+		Leave(node *WalkNode) WalkAction
+	}
+
+	// WalkFn is the callback of WalkFuncs.
+	WalkFn func(node *WalkNode) WalkAction
+
+	// WalkFuncs is a Visitor made of functions, any of them can be nil.
+	WalkFuncs struct {
+		OnEnter WalkFn
+		OnLeave WalkFn
+	}
+
+	// A WalkNode is a value met by Walk.
+	WalkNode struct {
+		Value Value
+		Kind  Kind
+		Path  []byte // like "Users[2].FirstName.String" : it's reused by Walk, so copy it to keep it
+		Depth int
+		Name  []byte // the name of the struct field, if the node is one
+		Tag   []byte // the tag of the struct field, if the node is one
+		Index int    // the index of the struct field or of the slice / array element, -1 otherwise
+		Key   Value  // the key of the map entry, if the node is one
+		Cycle bool   // the node is a pointer or a map already being walked : its children are not walked again
+	}
+
+	// An EqualOption alters the comparison made by DeepEqualWith.
+	EqualOption func(*equalState)
+
+	// CloneOption is a set of flags altering how Clone copies the values it can't (or shouldn't) deep copy.
+	CloneOption uint8
+
 	// Types
 	// -----
 
@@ -231,6 +348,22 @@ type (
 		u uncommonType
 	}
 	// (COMPILER)
+	uncommonChan struct {
+		chanType
+		u uncommonType
+	}
+
+	// A fieldScan represents an item on the fieldByNameFunc scan work list.
+	fieldScan struct {
+		typ   *structType
+		index []int
+	}
+
+	uncommonMap struct {
+		mapType
+		u uncommonType
+	}
+	// (COMPILER)
 	uncommonConcrete struct {
 		RType
 		u uncommonType
@@ -244,6 +377,14 @@ type (
 		Len       uintptr
 	}
 
+	// chanType represents a channel type.
+	// (COMPILER)
+	chanType struct {
+		RType    `reflect:"chan"`
+		ElemType *RType  // channel element type
+		dir      uintptr // channel direction (ChanDir)
+	}
+
 	// funcType represents a function type.
 	//
 	// A *Type for each in and out parameter is stored in an array that
@@ -262,6 +403,32 @@ type (
 		OutLen uint16 // top bit is set if last input parameter is ...
 	}
 
+	// funcTypeFixedN are the storage used by FuncOf : a funcType followed directly by its in and out parameters.
+	funcTypeFixed4 struct {
+		funcType
+		args [4]*RType
+	}
+	funcTypeFixed8 struct {
+		funcType
+		args [8]*RType
+	}
+	funcTypeFixed16 struct {
+		funcType
+		args [16]*RType
+	}
+	funcTypeFixed32 struct {
+		funcType
+		args [32]*RType
+	}
+	funcTypeFixed64 struct {
+		funcType
+		args [64]*RType
+	}
+	funcTypeFixed128 struct {
+		funcType
+		args [128]*RType
+	}
+
 	// ifaceMethod represents a method on an interface type
 	// (COMPILER)
 	ifaceMethod struct {
@@ -351,6 +518,16 @@ type (
 		bytes *byte
 	}
 
+	// A runtimeSelect is a single case passed to rselect.
+	// This must match ../runtime/select.go:/runtimeSelect
+	// (COMPILER)
+	runtimeSelect struct {
+		dir SelectDir      // SelectSend, SelectRecv or SelectDefault
+		typ *RType         // channel type
+		ch  unsafe.Pointer // channel
+		val unsafe.Pointer // ptr to data (SendDir) or ptr to receive buffer (RecvDir)
+	}
+
 	// Layout matches runtime.gobitvector (well enough).
 	bitVector struct {
 		num  uint32 // number of bits
@@ -493,6 +670,8 @@ type (
 	InspectTypeFn   func(typ *RType, name []byte, tag []byte, pack []byte, embedded, exported bool, offset uintptr, index int)
 	InspectValueFn  func(typ *RType, name []byte, tag []byte, pack []byte, embedded, exported bool, offset uintptr, index int, valPtr unsafe.Pointer)
 	MethodInspectFn func(name []byte, index int, flag Flag, inParams, outParams []*RType)
+	// IfaceMethodInspectFn receives the name, the package path (empty for exported names) and the signature (without receiver) of an interface method
+	IfaceMethodInspectFn func(name []byte, pkgPath []byte, signature *RType, index int)
 
 	BasicValue struct {
 		ptr  unsafe.Pointer
@@ -537,9 +716,239 @@ type (
 		Value
 	}
 
+	// A MapIter is an iterator for ranging over a map. See MapValue.Range.
+	MapIter struct {
+		m  MapValue
+		it unsafe.Pointer
+	}
+
 	SliceValue struct {
 		Value
 	}
+
+	ChanValue struct {
+		Value
+	}
+
+	// A SelectCase describes a single case in a select operation.
+	// The kind of case depends on Dir, the communication direction.
+	//
+	// If Dir is SelectDefault, the case represents a default case.
+	// Chan and Send must be zero Values.
+	//
+	// If Dir is SelectSend, the case represents a send operation.
+	// Normally Chan's underlying value must be a channel, and Send's underlying value must be
+	// assignable to the channel's element type. As a special case, if Chan is a zero Value,
+	// then the case is ignored, and the field Send will also be ignored and may be either zero
+	// or non-zero.
+	//
+	// If Dir is SelectRecv, the case represents a receive operation.
+	// Normally Chan's underlying value must be a channel and Send must be a zero Value.
+	// If Chan is a zero Value, then the case is ignored, but Send must still be a zero Value.
+	// When a receive operation is selected, the received Value is returned by Select.
+	SelectCase struct {
+		Dir  SelectDir // direction of case
+		Chan Value     // channel to use (for send or receive)
+		Send Value     // value to send (for send)
+	}
+
+	// Method represents a single method of a type, as returned by RType.Method.
+	Method struct {
+		// Name is the method name.
+		// PkgPath is the package path that qualifies a lower case (unexported)
+		// method name. It is empty for upper case (exported) method names.
+		Name    string
+		PkgPath string
+
+		Type       *RType // method type, with the receiver as first argument (interfaces : without receiver)
+		MethodType *RType // method type, without the receiver
+		Func       Value  // func with receiver as first argument (invalid for interface types)
+		Index      int    // index for RType.Method
+	}
+
+	// A StructPlan is an immutable description of a struct type, built once by PlanOf and shared by every caller.
+	// Neither the plan nor its fields should be modified.
+	StructPlan struct {
+		Type   *RType      // the struct type
+		Fields []PlanField // flattened fields : fields of embedded structs follow the embedded field itself
+		byName map[string]int
+	}
+
+	// A PlanField is a precomputed struct field : name and tag are already decoded, tag pairs already split.
+	PlanField struct {
+		Name     []byte
+		PkgPath  []byte // empty for exported fields
+		Tag      []byte // raw tag
+		Tags     []TagPair
+		Type     *RType
+		Offset   uintptr // offset from the start of the outer struct, meaningful only if Indirect is false
+		Index    []int   // index sequence for FieldByIndex
+		Depth    int     // 0 for the direct fields of the struct
+		Embedded bool
+		Exported bool
+		Indirect bool // the field is reached through an embedded pointer
+		roFlag   Flag
+	}
+
+	// A TagParser iterates, without allocating, over the `key:"value"` pairs of a struct tag. See ParseTag.
+	TagParser struct {
+		tag    []byte // the whole tag, for offsets
+		pos    int    // offset of the unparsed rest of the tag
+		offset int    // offset of the current pair, or of the syntax error
+		err    error
+		Tag    Tag // the current pair, valid after Next returned true
+	}
+
+	// A Tag is a single `key:"value"` pair of a struct tag. Key and Value share the memory of the parsed tag.
+	// Value is the raw content between the quotes : it differs from the unquoted value only when the tag uses backslash escapes.
+	Tag struct {
+		Key   []byte
+		Value []byte
+	}
+
+	// A TagError describes a malformed struct tag found by ValidateTags.
+	TagError struct {
+		Path   string // path of the field, from the validated type, like "User.Entity.Id"
+		Tag    string // the whole tag
+		Offset int    // byte offset of the problem inside Tag
+		Reason string
+	}
+
+	// A Change is a single difference found by Diff.
+	Change struct {
+		Path string // path of the value, like "Users[2].FirstName.String" (empty for the compared values themselves)
+		Op   DiffOp
+		Old  Value // invalid for DiffAdded
+		New  Value // invalid for DiffRemoved
+	}
+
+	// diffState holds the changes found so far and the pointers being compared, to stop on cycles.
+	diffState struct {
+		changes []Change
+		visited map[visit]bool
+	}
+
+	// equalState holds the options of DeepEqualWith, the comparisons in progress and the path to the first mismatch.
+	equalState struct {
+		visited        map[visit]bool
+		ignoreNames    map[string]bool
+		ignoreTags     []TagPair
+		comparers      map[*RType]func(x, y interface{}) bool
+		epsilon        float64
+		nanEqual       bool
+		nilEqualsEmpty bool
+		trackPath      bool
+		mismatch       []string // parts of the path to the first mismatch, innermost first
+	}
+
+	// hashState holds the pointers being hashed by Hash, to stop on cycles, and the number of cycles met so far.
+	hashState struct {
+		walking map[visit]bool
+		cycles  int
+	}
+
+	// walkState holds the visitor of Walk and the pointers and maps being walked, to stop on cycles.
+	walkState struct {
+		visitor Visitor
+		walking map[visit]bool
+		stopped bool
+	}
+
+	// cloneState holds the options of Clone and the copies made so far, so shared pointers, maps and slices stay shared.
+	cloneState struct {
+		options CloneOption
+		copies  map[cloneKey]unsafe.Pointer
+		arrays  []clonedArray // sorted by start
+	}
+
+	// cloneKey identifies an original pointer or map.
+	cloneKey struct {
+		ptr unsafe.Pointer
+		typ *RType
+	}
+
+	// clonedArray is the extent of an original backing array, from the data of a slice to its capacity, and its copy.
+	clonedArray struct {
+		start  uintptr
+		end    uintptr
+		elem   *RType
+		copied unsafe.Pointer
+	}
+
+	// diffKey is a map key and its printed form, used to sort map entries.
+	diffKey struct {
+		label []byte
+		key   Value
+	}
+
+	// A BindError describes a map entry which MapToStruct could not store into its field.
+	BindError struct {
+		Path string // path of the field, from the destination struct, like "Customer.Users[2].Username"
+		Err  error
+	}
+
+	// A NumError describes a failed number parsing by ParseInt, ParseUint, ParseFloat or ParseBool.
+	// Err is ErrSyntax or ErrRange. The input is not kept, so that failing does not allocate.
+	// It is returned by value (the error holds a NumError, not a *NumError).
+	NumError struct {
+		Func string // the failing function (ParseInt, ParseUint, ParseFloat, ParseBool)
+		Err  error  // the reason the conversion failed (ErrSyntax, ErrRange)
+	}
+
+	// decimal is a multiprecision decimal number, used by ParseFloat when the fast path cannot round correctly.
+	decimal struct {
+		d     [800]byte // digits, big-endian representation
+		nd    int       // number of digits used
+		dp    int       // decimal point
+		neg   bool      // negative flag
+		trunc bool      // discarded nonzero digits beyond d[:nd]
+	}
+
+	// floatInfo describes the layout of a float type.
+	floatInfo struct {
+		mantbits uint
+		expbits  uint
+		bias     int
+	}
+
+	// leftCheat gives the number of new digits introduced by a left shift of a decimal.
+	leftCheat struct {
+		delta  int    // number of new digits
+		cutoff string // minus one digit if original < a.
+	}
+
+	// jsonNameKey identifies a json name at a certain depth of embedding, for duplicate detection.
+	jsonNameKey struct {
+		name  string
+		depth int
+	}
+
+	// TagOptions iterates over a separated list : comma separated json style options or semicolon separated sql style settings.
+	TagOptions struct {
+		rest    []byte
+		sep     byte
+		current []byte
+	}
+
+	// A TagPair is a key and its unquoted value, from a tag in the conventional `key:"value" key2:"value2"` format.
+	TagPair struct {
+		Key   []byte
+		Value []byte
+	}
+
+	// A StructField describes a single field in a struct, as handed to StructOf.
+	StructField struct {
+		// Name is the field name. For embedded fields it is the name of the embedded type.
+		Name string
+		// PkgPath is the package path that qualifies a lower case (unexported)
+		// field name. It is empty for upper case (exported) field names.
+		PkgPath   string
+		Type      *RType  // field type
+		Tag       string  // field tag string
+		Offset    uintptr // offset within struct, in bytes
+		Index     []int   // index sequence for FieldByIndex
+		Anonymous bool    // is an embedded field
+	}
 )
 type З struct{}
 
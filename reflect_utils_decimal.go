@@ -0,0 +1,987 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import (
+	"math"
+)
+
+// Multiprecision decimal numbers, for ParseFloat and appendFloat (borrowed from strconv).
+// For floating-point formatting only; not general purpose.
+// Only operations are assign and (binary) left/right shift.
+// Can do binary floating point in multiprecision decimal precisely
+// because 2 divides 10; cannot do decimal floating point
+// in multiprecision binary precisely.
+
+const (
+	uintSize = 32 << (^uint(0) >> 63)
+	// Maximum shift that we can do in one pass without overflow.
+	// A uint has 32 or 64 bits, and we have to be able to accommodate 9<<k.
+	maxShift = uintSize - 4
+)
+
+var (
+	float32info = floatInfo{23, 8, -127}
+	float64info = floatInfo{52, 11, -1023}
+
+	// decimal power of ten to binary power of two.
+	powtab = []int{1, 3, 6, 9, 13, 16, 19, 23, 26}
+
+	// Exact powers of 10.
+	float64pow10 = []float64{
+		1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+		1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+		1e20, 1e21, 1e22,
+	}
+	float32pow10 = []float32{1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10}
+
+	// Cheat sheet for left shift: table indexed by shift count giving
+	// number of new digits that will be introduced by that shift.
+	//
+	// For example, leftcheats[4] = {2, "625"}.  That means that
+	// if we are shifting by 4 (multiplying by 16), it will add 2 digits
+	// when the string prefix is "625" through "999", and one fewer digit
+	// if the string prefix is "000" through "624".
+	//
+	// Credit for this trick goes to Ken.
+	leftcheats = []leftCheat{
+		// Leading digits of 1/2^i = 5^i.
+		// 5^23 is not an exact 64-bit floating point number,
+		// so have to use bc for the math.
+		// Go up to 60 to be large enough for 32bit and 64bit platforms.
+		{0, ""},
+		{1, "5"},                                           // * 2
+		{1, "25"},                                          // * 4
+		{1, "125"},                                         // * 8
+		{2, "625"},                                         // * 16
+		{2, "3125"},                                        // * 32
+		{2, "15625"},                                       // * 64
+		{3, "78125"},                                       // * 128
+		{3, "390625"},                                      // * 256
+		{3, "1953125"},                                     // * 512
+		{4, "9765625"},                                     // * 1024
+		{4, "48828125"},                                    // * 2048
+		{4, "244140625"},                                   // * 4096
+		{4, "1220703125"},                                  // * 8192
+		{5, "6103515625"},                                  // * 16384
+		{5, "30517578125"},                                 // * 32768
+		{5, "152587890625"},                                // * 65536
+		{6, "762939453125"},                                // * 131072
+		{6, "3814697265625"},                               // * 262144
+		{6, "19073486328125"},                              // * 524288
+		{7, "95367431640625"},                              // * 1048576
+		{7, "476837158203125"},                             // * 2097152
+		{7, "2384185791015625"},                            // * 4194304
+		{7, "11920928955078125"},                           // * 8388608
+		{8, "59604644775390625"},                           // * 16777216
+		{8, "298023223876953125"},                          // * 33554432
+		{8, "1490116119384765625"},                         // * 67108864
+		{9, "7450580596923828125"},                         // * 134217728
+		{9, "37252902984619140625"},                        // * 268435456
+		{9, "186264514923095703125"},                       // * 536870912
+		{10, "931322574615478515625"},                      // * 1073741824
+		{10, "4656612873077392578125"},                     // * 2147483648
+		{10, "23283064365386962890625"},                    // * 4294967296
+		{10, "116415321826934814453125"},                   // * 8589934592
+		{11, "582076609134674072265625"},                   // * 17179869184
+		{11, "2910383045673370361328125"},                  // * 34359738368
+		{11, "14551915228366851806640625"},                 // * 68719476736
+		{12, "72759576141834259033203125"},                 // * 137438953472
+		{12, "363797880709171295166015625"},                // * 274877906944
+		{12, "1818989403545856475830078125"},               // * 549755813888
+		{13, "9094947017729282379150390625"},               // * 1099511627776
+		{13, "45474735088646411895751953125"},              // * 2199023255552
+		{13, "227373675443232059478759765625"},             // * 4398046511104
+		{13, "1136868377216160297393798828125"},            // * 8796093022208
+		{14, "5684341886080801486968994140625"},            // * 17592186044416
+		{14, "28421709430404007434844970703125"},           // * 35184372088832
+		{14, "142108547152020037174224853515625"},          // * 70368744177664
+		{15, "710542735760100185871124267578125"},          // * 140737488355328
+		{15, "3552713678800500929355621337890625"},         // * 281474976710656
+		{15, "17763568394002504646778106689453125"},        // * 562949953421312
+		{16, "88817841970012523233890533447265625"},        // * 1125899906842624
+		{16, "444089209850062616169452667236328125"},       // * 2251799813685248
+		{16, "2220446049250313080847263336181640625"},      // * 4503599627370496
+		{16, "11102230246251565404236316680908203125"},     // * 9007199254740992
+		{17, "55511151231257827021181583404541015625"},     // * 18014398509481984
+		{17, "277555756156289135105907917022705078125"},    // * 36028797018963968
+		{17, "1387778780781445675529539585113525390625"},   // * 72057594037927936
+		{18, "6938893903907228377647697925567626953125"},   // * 144115188075855872
+		{18, "34694469519536141888238489627838134765625"},  // * 288230376151711744
+		{18, "173472347597680709441192448139190673828125"}, // * 576460752303423488
+		{19, "867361737988403547205962240695953369140625"}, // * 1152921504606846976
+	}
+)
+
+func lowerASCII(c byte) byte {
+	return c | ('x' - 'X')
+}
+
+// special parses the infinities and NaN, in any case.
+func special(s string) (float64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	sign := 1
+	nsign := 0
+	switch s[0] {
+	case '+', '-':
+		if s[0] == '-' {
+			sign = -1
+		}
+		nsign = 1
+		s = s[1:]
+		fallthrough
+	case 'i', 'I':
+		n := len(s)
+		if n == 3 && equalFoldASCII(s, "inf") || n == 8 && equalFoldASCII(s, "infinity") {
+			return math.Inf(sign), true
+		}
+	case 'n', 'N':
+		if nsign == 0 && equalFoldASCII(s, "nan") {
+			return math.NaN(), true
+		}
+	}
+	return 0, false
+}
+
+// equalFoldASCII reports whether s, in any case, is the lower case ASCII word lower.
+func equalFoldASCII(s, lower string) bool {
+	if len(s) != len(lower) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if lowerASCII(s[i]) != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readExponent reads the optional exponent of a float, starting at s[i], and returns it with the index after it.
+// A very large exponent is only read up to 10000 : it doesn't matter if it's not the exact number.
+// Underscores are skipped : readFloat checks where they are.
+func readExponent(s string, i int) (int, int, bool) {
+	if i >= len(s) || lowerASCII(s[i]) != 'e' {
+		return 0, i, true
+	}
+	i++
+	if i >= len(s) {
+		return 0, i, false
+	}
+	esign := 1
+	if s[i] == '+' {
+		i++
+	} else if s[i] == '-' {
+		i++
+		esign = -1
+	}
+	if i >= len(s) || s[i] < '0' || s[i] > '9' {
+		return 0, i, false
+	}
+	e := 0
+	for ; i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '_'); i++ {
+		if s[i] == '_' {
+			continue
+		}
+		if e < 10000 {
+			e = e*10 + int(s[i]) - '0'
+		}
+	}
+	return e * esign, i, true
+}
+
+func (a *decimal) set(s string) bool {
+	i := 0
+	a.neg = false
+	a.trunc = false
+
+	// optional sign
+	if i >= len(s) {
+		return false
+	}
+	switch {
+	case s[i] == '+':
+		i++
+	case s[i] == '-':
+		a.neg = true
+		i++
+	}
+
+	// digits
+	sawdot := false
+	sawdigits := false
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '.':
+			if sawdot {
+				return false
+			}
+			sawdot = true
+			a.dp = a.nd
+			continue
+
+		case s[i] == '_':
+			// readFloat checked the underscores
+			continue
+
+		case '0' <= s[i] && s[i] <= '9':
+			sawdigits = true
+			if s[i] == '0' && a.nd == 0 { // ignore leading zeros
+				a.dp--
+				continue
+			}
+			if a.nd < len(a.d) {
+				a.d[a.nd] = s[i]
+				a.nd++
+			} else if s[i] != '0' {
+				a.trunc = true
+			}
+			continue
+		}
+		break
+	}
+	if !sawdigits {
+		return false
+	}
+	if !sawdot {
+		a.dp = a.nd
+	}
+
+	// optional exponent moves decimal point.
+	e, i, ok := readExponent(s, i)
+	if !ok {
+		return false
+	}
+	a.dp += e
+	return i == len(s)
+}
+
+// readFloat reads a decimal mantissa and exponent from a float string representation.
+// It returns ok==false if the number is invalid, underscores included : they must separate digits, like in Go literals.
+func readFloat(s string) (mantissa uint64, exp int, neg, trunc, ok bool) {
+	const uint64digits = 19
+	i := 0
+
+	// optional sign
+	if i >= len(s) {
+		return
+	}
+	switch {
+	case s[i] == '+':
+		i++
+	case s[i] == '-':
+		neg = true
+		i++
+	}
+
+	// digits
+	sawdot := false
+	sawdigits := false
+	underscores := false
+	nd := 0
+	ndMant := 0
+	dp := 0
+	for ; i < len(s); i++ {
+		switch c := s[i]; true {
+		case c == '.':
+			if sawdot {
+				return
+			}
+			sawdot = true
+			dp = nd
+			continue
+
+		case c == '_':
+			underscores = true
+			continue
+
+		case '0' <= c && c <= '9':
+			sawdigits = true
+			if c == '0' && nd == 0 { // ignore leading zeros
+				dp--
+				continue
+			}
+			nd++
+			if ndMant < uint64digits {
+				mantissa *= 10
+				mantissa += uint64(c - '0')
+				ndMant++
+			} else if c != '0' {
+				trunc = true
+			}
+			continue
+		}
+		break
+	}
+	if !sawdigits {
+		return
+	}
+	if !sawdot {
+		dp = nd
+	}
+
+	// optional exponent moves decimal point.
+	expStart := i
+	e, i, valid := readExponent(s, i)
+	if !valid || i != len(s) {
+		return
+	}
+	for ; !underscores && expStart < i; expStart++ {
+		underscores = s[expStart] == '_'
+	}
+	if underscores && !underscoreOK(s) {
+		return
+	}
+	dp += e
+
+	if mantissa != 0 {
+		exp = dp - ndMant
+	}
+	ok = true
+	return
+}
+
+// floatBits returns the bits of the float described by flt which is the closest to a, and whether it overflowed.
+func (a *decimal) floatBits(flt *floatInfo) (b uint64, overflow bool) {
+	var exp int
+	var mant uint64
+
+	// Zero is always a special case.
+	if a.nd == 0 {
+		mant = 0
+		exp = flt.bias
+		goto out
+	}
+
+	// Obvious overflow/underflow.
+	// These bounds are for 64-bit floats.
+	if a.dp > 310 {
+		goto overflow
+	}
+	if a.dp < -330 {
+		// zero
+		mant = 0
+		exp = flt.bias
+		goto out
+	}
+
+	// Scale by powers of two until in range [0.5, 1.0)
+	exp = 0
+	for a.dp > 0 {
+		var n int
+		if a.dp >= len(powtab) {
+			n = 27
+		} else {
+			n = powtab[a.dp]
+		}
+		a.shift(-n)
+		exp += n
+	}
+	for a.dp < 0 || a.dp == 0 && a.d[0] < '5' {
+		var n int
+		if -a.dp >= len(powtab) {
+			n = 27
+		} else {
+			n = powtab[-a.dp]
+		}
+		a.shift(n)
+		exp -= n
+	}
+
+	// Our range is [0.5,1) but floating point range is [1,2).
+	exp--
+
+	// Minimum representable exponent is flt.bias+1.
+	// If the exponent is smaller, move it up and adjust a accordingly.
+	if exp < flt.bias+1 {
+		n := flt.bias + 1 - exp
+		a.shift(-n)
+		exp += n
+	}
+
+	if exp-flt.bias >= 1<<flt.expbits-1 {
+		goto overflow
+	}
+
+	// Extract 1+flt.mantbits bits.
+	a.shift(int(1 + flt.mantbits))
+	mant = a.roundedInteger()
+
+	// Rounding might have added a bit; shift down.
+	if mant == 2<<flt.mantbits {
+		mant >>= 1
+		exp++
+		if exp-flt.bias >= 1<<flt.expbits-1 {
+			goto overflow
+		}
+	}
+
+	// Denormalized?
+	if mant&(1<<flt.mantbits) == 0 {
+		exp = flt.bias
+	}
+	goto out
+
+overflow:
+	// ±Inf
+	mant = 0
+	exp = 1<<flt.expbits - 1 + flt.bias
+	overflow = true
+
+out:
+	// Assemble bits.
+	bits := mant & (uint64(1)<<flt.mantbits - 1)
+	bits |= uint64((exp-flt.bias)&(1<<flt.expbits-1)) << flt.mantbits
+	if a.neg {
+		bits |= 1 << flt.mantbits << flt.expbits
+	}
+	return bits, overflow
+}
+
+// atof64exact converts the decimal representation to a 64-bit float entirely in floating-point math, when it is exact.
+// Three common cases:
+//
+//	value is exact integer
+//	value is exact integer * exact power of ten
+//	value is exact integer / exact power of ten
+//
+// These all produce potentially inexact but correctly rounded answers.
+func atof64exact(mantissa uint64, exp int, neg bool) (f float64, ok bool) {
+	if mantissa>>float64info.mantbits != 0 {
+		return
+	}
+	f = float64(mantissa)
+	if neg {
+		f = -f
+	}
+	switch {
+	case exp == 0:
+		// an integer.
+		return f, true
+	// Exact integers are <= 10^15.
+	// Exact powers of ten are <= 10^22.
+	case exp > 0 && exp <= 15+22: // int * 10^k
+		// If exponent is big but number of digits is not,
+		// can move a few zeros into the integer part.
+		if exp > 22 {
+			f *= float64pow10[exp-22]
+			exp = 22
+		}
+		if f > 1e15 || f < -1e15 {
+			// the exponent was really too large.
+			return
+		}
+		return f * float64pow10[exp], true
+	case exp < 0 && exp >= -22: // int / 10^k
+		return f / float64pow10[-exp], true
+	}
+	return
+}
+
+// atof32exact is atof64exact for 32-bit floats.
+func atof32exact(mantissa uint64, exp int, neg bool) (f float32, ok bool) {
+	if mantissa>>float32info.mantbits != 0 {
+		return
+	}
+	f = float32(mantissa)
+	if neg {
+		f = -f
+	}
+	switch {
+	case exp == 0:
+		return f, true
+	// Exact integers are <= 10^7.
+	// Exact powers of ten are <= 10^10.
+	case exp > 0 && exp <= 7+10: // int * 10^k
+		// If exponent is big but number of digits is not,
+		// can move a few zeros into the integer part.
+		if exp > 10 {
+			f *= float32pow10[exp-10]
+			exp = 10
+		}
+		if f > 1e7 || f < -1e7 {
+			// the exponent was really too large.
+			return
+		}
+		return f * float32pow10[exp], true
+	case exp < 0 && exp >= -10: // int / 10^k
+		return f / float32pow10[-exp], true
+	}
+	return
+}
+
+// trim trailing zeros from number.
+func (a *decimal) trim() {
+	for a.nd > 0 && a.d[a.nd-1] == '0' {
+		a.nd--
+	}
+	if a.nd == 0 {
+		a.dp = 0
+	}
+}
+
+// rightShift shifts a right (/ 2) by k bits. k <= maxShift to avoid overflow.
+func (a *decimal) rightShift(k uint) {
+	r := 0 // read pointer
+	w := 0 // write pointer
+
+	// Pick up enough leading digits to cover first shift.
+	var n uint
+	for ; n>>k == 0; r++ {
+		if r >= a.nd {
+			if n == 0 {
+				// a == 0; shouldn't get here, but handle anyway.
+				a.nd = 0
+				return
+			}
+			for n>>k == 0 {
+				n = n * 10
+				r++
+			}
+			break
+		}
+		c := uint(a.d[r])
+		n = n*10 + c - '0'
+	}
+	a.dp -= r - 1
+
+	var mask uint = (1 << k) - 1
+
+	// Pick up a digit, put down a digit.
+	for ; r < a.nd; r++ {
+		c := uint(a.d[r])
+		dig := n >> k
+		n &= mask
+		a.d[w] = byte(dig + '0')
+		w++
+		n = n*10 + c - '0'
+	}
+
+	// Put down extra digits.
+	for n > 0 {
+		dig := n >> k
+		n &= mask
+		if w < len(a.d) {
+			a.d[w] = byte(dig + '0')
+			w++
+		} else if dig > 0 {
+			a.trunc = true
+		}
+		n = n * 10
+	}
+
+	a.nd = w
+	a.trim()
+}
+
+// prefixIsLessThan reports whether the leading prefix of b is lexicographically less than s.
+func prefixIsLessThan(b []byte, s string) bool {
+	for i := 0; i < len(s); i++ {
+		if i >= len(b) {
+			return true
+		}
+		if b[i] != s[i] {
+			return b[i] < s[i]
+		}
+	}
+	return false
+}
+
+// leftShift shifts a left (* 2) by k bits. k <= maxShift to avoid overflow.
+func (a *decimal) leftShift(k uint) {
+	delta := leftcheats[k].delta
+	if prefixIsLessThan(a.d[0:a.nd], leftcheats[k].cutoff) {
+		delta--
+	}
+
+	r := a.nd         // read index
+	w := a.nd + delta // write index
+
+	// Pick up a digit, put down a digit.
+	var n uint
+	for r--; r >= 0; r-- {
+		n += (uint(a.d[r]) - '0') << k
+		quo := n / 10
+		rem := n - 10*quo
+		w--
+		if w < len(a.d) {
+			a.d[w] = byte(rem + '0')
+		} else if rem != 0 {
+			a.trunc = true
+		}
+		n = quo
+	}
+
+	// Put down extra digits.
+	for n > 0 {
+		quo := n / 10
+		rem := n - 10*quo
+		w--
+		if w < len(a.d) {
+			a.d[w] = byte(rem + '0')
+		} else if rem != 0 {
+			a.trunc = true
+		}
+		n = quo
+	}
+
+	a.nd += delta
+	if a.nd >= len(a.d) {
+		a.nd = len(a.d)
+	}
+	a.dp += delta
+	a.trim()
+}
+
+// shift shifts a left (k > 0) or right (k < 0) by k bits.
+func (a *decimal) shift(k int) {
+	switch {
+	case a.nd == 0:
+		// nothing to do: a == 0
+	case k > 0:
+		for k > maxShift {
+			a.leftShift(maxShift)
+			k -= maxShift
+		}
+		a.leftShift(uint(k))
+	case k < 0:
+		for k < -maxShift {
+			a.rightShift(maxShift)
+			k += maxShift
+		}
+		a.rightShift(uint(-k))
+	}
+}
+
+// shouldRoundUp reports whether a chopped at nd digits should round up.
+func (a *decimal) shouldRoundUp(nd int) bool {
+	if nd < 0 || nd >= a.nd {
+		return false
+	}
+	if a.d[nd] == '5' && nd+1 == a.nd { // exactly halfway - round to even
+		// if we truncated, a little higher than what's recorded - always round up
+		if a.trunc {
+			return true
+		}
+		return nd > 0 && (a.d[nd-1]-'0')%2 == 1
+	}
+	// not halfway - digit tells all
+	return a.d[nd] >= '5'
+}
+
+// roundedInteger extracts the integer part of a, rounded appropriately. No guarantees about overflow.
+func (a *decimal) roundedInteger() uint64 {
+	if a.dp > 20 {
+		return 0xFFFFFFFFFFFFFFFF
+	}
+	var i int
+	n := uint64(0)
+	for i = 0; i < a.dp && i < a.nd; i++ {
+		n = n*10 + uint64(a.d[i]-'0')
+	}
+	for ; i < a.dp; i++ {
+		n *= 10
+	}
+	if a.shouldRoundUp(a.dp) {
+		n++
+	}
+	return n
+}
+
+// appendFloat appends the shortest decimal form of f which parses back to f (with the given bitSize, 32 or 64) to buf,
+// like strconv.AppendFloat(buf, f, 'g', -1, bitSize) does : exponents below -4 or from 6 on use the %e format.
+func appendFloat(buf []byte, f float64, bitSize int) []byte {
+	var bits uint64
+	flt := &float64info
+	if bitSize == 32 {
+		bits = uint64(math.Float32bits(float32(f)))
+		flt = &float32info
+	} else {
+		bits = math.Float64bits(f)
+	}
+
+	neg := bits>>(flt.expbits+flt.mantbits) != 0
+	exp := int(bits>>flt.mantbits) & (1<<flt.expbits - 1)
+	mant := bits & (uint64(1)<<flt.mantbits - 1)
+
+	switch exp {
+	case 1<<flt.expbits - 1:
+		// Inf, NaN
+		switch {
+		case mant != 0:
+			return append(buf, "NaN"...)
+		case neg:
+			return append(buf, "-Inf"...)
+		}
+		return append(buf, "+Inf"...)
+
+	case 0:
+		// denormalized
+		exp++
+
+	default:
+		// add implicit top bit
+		mant |= uint64(1) << flt.mantbits
+	}
+	exp += flt.bias
+
+	var d decimal
+	d.assign(mant)
+	d.shift(exp - int(flt.mantbits))
+	d.roundShortest(mant, exp, flt)
+
+	if neg {
+		buf = append(buf, '-')
+	}
+	// %e is used if the exponent from the conversion is less than -4 or greater than or equal to the precision,
+	// which is 6 for the shortest representation.
+	if eexp := d.dp - 1; d.nd > 0 && (eexp < -4 || eexp >= 6) {
+		// -d.ddddde±dd
+		buf = append(buf, d.d[0])
+		if d.nd > 1 {
+			buf = append(append(buf, '.'), d.d[1:d.nd]...)
+		}
+		buf = append(buf, 'e')
+		if eexp < 0 {
+			buf = append(buf, '-')
+			eexp = -eexp
+		} else {
+			buf = append(buf, '+')
+		}
+		if eexp < 10 {
+			buf = append(buf, '0')
+		}
+		return appendUint(buf, uint64(eexp), 10)
+	}
+	// -ddddddd.ddddd : integer part, padded with zeros as needed, then fraction
+	if d.dp > 0 {
+		m := d.dp
+		if m > d.nd {
+			m = d.nd
+		}
+		buf = append(buf, d.d[:m]...)
+		for ; m < d.dp; m++ {
+			buf = append(buf, '0')
+		}
+	} else {
+		buf = append(buf, '0')
+	}
+	if d.nd > d.dp {
+		buf = append(buf, '.')
+		for i := d.dp; i < d.nd; i++ {
+			if i < 0 {
+				buf = append(buf, '0')
+			} else {
+				buf = append(buf, d.d[i])
+			}
+		}
+	}
+	return buf
+}
+
+// assign sets a to the integer v.
+func (a *decimal) assign(v uint64) {
+	var buf [24]byte
+
+	// Write reversed decimal in buf.
+	n := 0
+	for v > 0 {
+		v1 := v / 10
+		v -= 10 * v1
+		buf[n] = byte(v + '0')
+		n++
+		v = v1
+	}
+
+	// Reverse again to produce forward decimal in a.d.
+	a.nd = 0
+	for n--; n >= 0; n-- {
+		a.d[a.nd] = buf[n]
+		a.nd++
+	}
+	a.dp = a.nd
+	a.trim()
+}
+
+// round rounds a to nd digits (or fewer). If nd is zero, it means we're rounding just to the left of the digits,
+// as in 0.09 -> 0.1.
+func (a *decimal) round(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	if a.shouldRoundUp(nd) {
+		a.roundUp(nd)
+	} else {
+		a.roundDown(nd)
+	}
+}
+
+// roundDown rounds a down to nd digits (or fewer).
+func (a *decimal) roundDown(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	a.nd = nd
+	a.trim()
+}
+
+// roundUp rounds a up to nd digits (or fewer).
+func (a *decimal) roundUp(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+
+	// round up
+	for i := nd - 1; i >= 0; i-- {
+		c := a.d[i]
+		if c < '9' { // can stop after this digit
+			a.d[i]++
+			a.nd = i + 1
+			return
+		}
+	}
+
+	// Number is all 9s.
+	// Change to single 1 with adjusted decimal point.
+	a.d[0] = '1'
+	a.nd = 1
+	a.dp++
+}
+
+// roundShortest rounds a (= mant * 2^exp) to the shortest number of digits that will let the original floating point
+// value be precisely reconstructed.
+func (a *decimal) roundShortest(mant uint64, exp int, flt *floatInfo) {
+	// If mantissa is zero, the number is zero; stop now.
+	if mant == 0 {
+		a.nd = 0
+		return
+	}
+
+	// Compute upper and lower such that any decimal number between upper and lower (possibly inclusive)
+	// will round to the original floating point number.
+
+	// We may see at once that the number is already shortest.
+	//
+	// Suppose a is not denormal, so that 2^exp <= a < 10^dp.
+	// The closest shorter number is at least 10^(dp-nd) away.
+	// The lower/upper bounds computed below are at distance at most 2^(exp-mantbits).
+	//
+	// So the number is already shortest if 10^(dp-nd) > 2^(exp-mantbits),
+	// or equivalently log2(10)*(dp-nd) > exp-mantbits.
+	// It is true if 332/100*(dp-nd) >= exp-mantbits (log2(10) > 3.32).
+	minexp := flt.bias + 1 // minimum possible exponent
+	if exp > minexp && 332*(a.dp-a.nd) >= 100*(exp-int(flt.mantbits)) {
+		// The number is already shortest.
+		return
+	}
+
+	// d = mant << (exp - mantbits)
+	// Next highest floating point number is mant+1 << exp-mantbits.
+	// Our upper bound is halfway between, mant*2+1 << exp-mantbits-1.
+	var upper decimal
+	upper.assign(mant*2 + 1)
+	upper.shift(exp - int(flt.mantbits) - 1)
+
+	// d = mant << (exp - mantbits)
+	// Next lowest floating point number is mant-1 << exp-mantbits,
+	// unless mant-1 drops the significant bit and exp is not the minimum exp,
+	// in which case the next lowest is mant*2-1 << exp-mantbits-1.
+	// Either way, call it mantlo << explo-mantbits.
+	// Our lower bound is halfway between, mantlo*2+1 << explo-mantbits-1.
+	var mantlo uint64
+	var explo int
+	if mant > 1<<flt.mantbits || exp == minexp {
+		mantlo = mant - 1
+		explo = exp
+	} else {
+		mantlo = mant*2 - 1
+		explo = exp - 1
+	}
+	var lower decimal
+	lower.assign(mantlo*2 + 1)
+	lower.shift(explo - int(flt.mantbits) - 1)
+
+	// The upper and lower bounds are possible outputs only if the original mantissa is even,
+	// so that IEEE round-to-even would round to the original mantissa and not the neighbors.
+	inclusive := mant%2 == 0
+
+	// As we walk the digits we want to know whether rounding up would fall within the upper bound.
+	// This is tracked by upperdelta:
+	//
+	// If upperdelta == 0, the digits of a and upper are the same so far.
+	//
+	// If upperdelta == 1, we saw a difference of 1 between a and upper on a previous digit
+	// and subsequently only 9s for a and 0s for upper.
+	// (Thus rounding up may fall outside the bound, if it is exclusive.)
+	//
+	// If upperdelta == 2, then the difference is greater than 1 and we know that rounding up falls within the bound.
+	var upperdelta uint8
+
+	// Now we can figure out the minimum number of digits required.
+	// Walk along until a has distinguished itself from upper and lower.
+	for ui := 0; ; ui++ {
+		// lower, a, and upper may have the decimal points at different places.
+		// In this case upper is the longest, so we iterate from ui==0 and start li and mi at (possibly) -1.
+		mi := ui - upper.dp + a.dp
+		if mi >= a.nd {
+			break
+		}
+		li := ui - upper.dp + lower.dp
+		l := byte('0') // lower digit
+		if li >= 0 && li < lower.nd {
+			l = lower.d[li]
+		}
+		m := byte('0') // middle digit
+		if mi >= 0 {
+			m = a.d[mi]
+		}
+		u := byte('0') // upper digit
+		if ui < upper.nd {
+			u = upper.d[ui]
+		}
+
+		// Okay to round down (truncate) if lower has a different digit or if lower is inclusive
+		// and is exactly the result of rounding down (i.e., and we have reached the final digit of lower).
+		okdown := l != m || inclusive && li+1 == lower.nd
+
+		switch {
+		case upperdelta == 0 && m+1 < u:
+			// Example:
+			// m = 12345xxx
+			// u = 12347xxx
+			upperdelta = 2
+		case upperdelta == 0 && m != u:
+			// Example:
+			// m = 12345xxx
+			// u = 12346xxx
+			upperdelta = 1
+		case upperdelta == 1 && (m != '9' || u != '0'):
+			// Example:
+			// m = 1234598x
+			// u = 1234600x
+			upperdelta = 2
+		}
+		// Okay to round up if upper has a different digit and either upper is inclusive
+		// or upper is bigger than the result of rounding up.
+		okup := upperdelta > 0 && (inclusive || upperdelta > 1 || ui+1 < upper.nd)
+
+		// If it's okay to do either, then round to the nearest one.
+		// If it's okay to do only one, do it.
+		switch {
+		case okdown && okup:
+			a.round(mi + 1)
+			return
+		case okdown:
+			a.roundDown(mi + 1)
+			return
+		case okup:
+			a.roundUp(mi + 1)
+			return
+		}
+	}
+}
@@ -22,12 +22,22 @@ type visit struct {
 // comparisons that have already been seen, which allows short circuiting on
 // recursive types.
 func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
+	state := equalState{visited: visited}
+	return state.equal(v1, v2, depth)
+}
+
+// equal is deepValueEqual, altered by the options of DeepEqualWith.
+// When tracking the path, each level appends its part of the path to the first mismatch on the way back.
+func (s *equalState) equal(v1, v2 Value, depth int) bool {
 	if !v1.IsValid() || !v2.IsValid() {
 		return v1.IsValid() == v2.IsValid()
 	}
 	if v1.Type != v2.Type {
 		return false
 	}
+	if comparer, ok := s.comparers[v1.Type]; ok {
+		return comparer(v1.valueInterface(), v2.valueInterface())
+	}
 
 	// We want to avoid putting more in the visited map than we need to.
 	// For any possible reference cycle that might be encountered,
@@ -52,12 +62,12 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 		// Short circuit if references are already seen.
 		typ := v1.Type
 		v := visit{addr1, addr2, typ}
-		if visited[v] {
+		if s.visited[v] {
 			return true
 		}
 
 		// Remember for later.
-		visited[v] = true
+		s.visited[v] = true
 	}
 
 	switch v1.Kind() {
@@ -73,17 +83,20 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 			offset2 := uintptr(i) * typ2.size
 			val1 := add(v1.Ptr, offset1)
 			val2 := add(v2.Ptr, offset2)
-			if !deepValueEqual(Value{Type: typ1, Ptr: val1, Flag: fl1}, Value{Type: typ2, Ptr: val2, Flag: fl2}, visited, depth+1) {
+			if !s.equal(Value{Type: typ1, Ptr: val1, Flag: fl1}, Value{Type: typ2, Ptr: val2, Flag: fl2}, depth+1) {
+				if s.trackPath {
+					s.mismatchAt("[" + I2A(i, -1) + "]")
+				}
 				return false
 			}
 		}
 		return true
 	case Slice:
-		if v1.IsNil() != v2.IsNil() {
-			return false
-		}
 		s1 := (*sliceHeader)(v1.Ptr)
 		s2 := (*sliceHeader)(v2.Ptr)
+		if v1.IsNil() != v2.IsNil() && !(s.nilEqualsEmpty && s1.Len == 0 && s2.Len == 0) {
+			return false
+		}
 		if s1.Len != s2.Len {
 			return false
 		}
@@ -98,7 +111,10 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 		for i := 0; i < s1.Len; i++ {
 			val1 := arrayAt(s1.Data, i, typ1.size)
 			val2 := arrayAt(s2.Data, i, typ2.size)
-			if !deepValueEqual(Value{Type: typ1, Ptr: val1, Flag: fl1}, Value{Type: typ2, Ptr: val2, Flag: fl2}, visited, depth+1) {
+			if !s.equal(Value{Type: typ1, Ptr: val1, Flag: fl1}, Value{Type: typ2, Ptr: val2, Flag: fl2}, depth+1) {
+				if s.trackPath {
+					s.mismatchAt("[" + I2A(i, -1) + "]")
+				}
 				return false
 			}
 		}
@@ -107,24 +123,30 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 		if v1.IsNil() || v2.IsNil() {
 			return v1.IsNil() == v2.IsNil()
 		}
-		return deepValueEqual(v1.Iface(), v2.Iface(), visited, depth+1)
+		return s.equal(v1.Iface(), v2.Iface(), depth+1)
 	case Ptr:
 		if v1.pointer() == v2.pointer() {
 			return true
 		}
-		return deepValueEqual(v1.Deref(), v2.Deref(), visited, depth+1)
+		return s.equal(v1.Deref(), v2.Deref(), depth+1)
 	case Struct:
 		sv1 := StructValue{Value: v1}
 		sv2 := StructValue{Value: v2}
-		numFields := len(sv1.Type.convToStruct().fields)
-		for i, n := 0, numFields; i < n; i++ {
-			if !deepValueEqual(sv1.Field(i), sv2.Field(i), visited, depth+1) {
+		fields := sv1.Type.convToStruct().fields
+		for i, n := 0, len(fields); i < n; i++ {
+			if s.ignored(&fields[i]) {
+				continue
+			}
+			if !s.equal(sv1.Field(i), sv2.Field(i), depth+1) {
+				if s.trackPath {
+					s.mismatchAt("." + string(fields[i].name.name()))
+				}
 				return false
 			}
 		}
 		return true
 	case Map:
-		if v1.IsNil() != v2.IsNil() {
+		if v1.IsNil() != v2.IsNil() && !(s.nilEqualsEmpty && maplen(v1.pointer()) == 0 && maplen(v2.pointer()) == 0) {
 			return false
 		}
 		if maplen(v1.pointer()) != maplen(v2.pointer()) {
@@ -138,7 +160,10 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 		for _, k := range v1map.MapKeys() {
 			val1 := v1map.MapIndex(k)
 			val2 := v2map.MapIndex(k)
-			if !val1.IsValid() || !val2.IsValid() || !deepValueEqual(v1map.MapIndex(k), v2map.MapIndex(k), visited, depth+1) {
+			if !val1.IsValid() || !val2.IsValid() || !s.equal(v1map.MapIndex(k), v2map.MapIndex(k), depth+1) {
+				if s.trackPath {
+					s.mismatchAt("[" + string(appendKeyLabel(nil, k)) + "]")
+				}
 				return false
 			}
 		}
@@ -151,12 +176,56 @@ func deepValueEqual(v1, v2 Value, visited map[visit]bool, depth int) bool {
 		}
 		// Can't do better than this:
 		return false
+	case Float32, Float64:
+		return s.floatEqual(v1.Float().Get(), v2.Float().Get())
+	case Complex64, Complex128:
+		c1, c2 := v1.Complex().Get(), v2.Complex().Get()
+		return s.floatEqual(real(c1), real(c2)) && s.floatEqual(imag(c1), imag(c2))
 	default:
 		// Normal equality suffices
 		return v1.valueInterface() == v2.valueInterface()
 	}
 }
 
+// mismatchAt records part of the path to the first mismatch : the innermost part comes first.
+// Callers check trackPath before building the part, so that DeepEqual doesn't allocate for a path it doesn't report.
+func (s *equalState) mismatchAt(part string) {
+	s.mismatch = append(s.mismatch, part)
+}
+
+// ignored reports whether the field is excluded from the comparison, by its name or by its tag.
+func (s *equalState) ignored(field *structField) bool {
+	if len(s.ignoreNames) > 0 && s.ignoreNames[string(field.name.name())] {
+		return true
+	}
+	if len(s.ignoreTags) == 0 {
+		return false
+	}
+	parser := ParseTag(field.name.tag())
+	for i := range s.ignoreTags {
+		found, ok := parser.Lookup(s.ignoreTags[i].Key)
+		if ok && (len(s.ignoreTags[i].Value) == 0 || string(found.Value) == string(s.ignoreTags[i].Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *equalState) floatEqual(f1, f2 float64) bool {
+	if f1 == f2 {
+		return true
+	}
+	if s.nanEqual && f1 != f1 && f2 != f2 {
+		return true
+	}
+	diff := f1 - f2
+	if diff < 0 {
+		diff = -diff
+	}
+	// false for NaNs and infinities
+	return diff <= s.epsilon
+}
+
 // UnsafeAddr returns a pointer to v's data.
 // It is for advanced clients that also import the "unsafe" package.
 // It panics if v is not addressable.
@@ -236,3 +305,84 @@ func DeepEqual(x, y interface{}) bool {
 	}
 	return deepValueEqual(v1, v2, make(map[visit]bool), 0)
 }
+
+// DeepEqualWith reports whether x and y are deeply equal, like DeepEqual does, as altered by the given options.
+// When they are not, it also returns the path to the first mismatch, like "Users[2].FirstName.String"
+// (empty when x and y themselves differ, for example by their types).
+func DeepEqualWith(x, y interface{}, options ...EqualOption) (bool, string) {
+	if x == nil || y == nil {
+		return x == y, ""
+	}
+	v1 := ReflectOn(x)
+	v2 := ReflectOn(y)
+	if v1.Type != v2.Type {
+		return false, ""
+	}
+	state := equalState{visited: make(map[visit]bool), trackPath: true}
+	for _, option := range options {
+		option(&state)
+	}
+	if state.equal(v1, v2, 0) {
+		return true, ""
+	}
+	var path []byte
+	for i := len(state.mismatch) - 1; i >= 0; i-- {
+		path = append(path, state.mismatch[i]...)
+	}
+	if len(path) > 0 && path[0] == '.' {
+		path = path[1:]
+	}
+	return false, string(path)
+}
+
+// IgnoreFields makes DeepEqualWith skip the struct fields with the given names, at any depth.
+func IgnoreFields(names ...string) EqualOption {
+	return func(s *equalState) {
+		if s.ignoreNames == nil {
+			s.ignoreNames = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			s.ignoreNames[name] = true
+		}
+	}
+}
+
+// IgnoreTagged makes DeepEqualWith skip the struct fields having key in their tag with the given value,
+// or with any value if value is empty : IgnoreTagged("json", "-") or IgnoreTagged("volatile", "").
+func IgnoreTagged(key, value string) EqualOption {
+	return func(s *equalState) {
+		s.ignoreTags = append(s.ignoreTags, TagPair{Key: []byte(key), Value: []byte(value)})
+	}
+}
+
+// CompareWith makes DeepEqualWith compare the values of type typ with equal, instead of walking through them.
+// For example, time.Time values can be compared with their Equal method.
+func CompareWith(typ *RType, equal func(x, y interface{}) bool) EqualOption {
+	return func(s *equalState) {
+		if s.comparers == nil {
+			s.comparers = make(map[*RType]func(x, y interface{}) bool)
+		}
+		s.comparers[typ] = equal
+	}
+}
+
+// FloatEpsilon makes DeepEqualWith consider floats (and the parts of complex numbers) equal when they differ by at most epsilon.
+func FloatEpsilon(epsilon float64) EqualOption {
+	return func(s *equalState) {
+		s.epsilon = epsilon
+	}
+}
+
+// NaNEqual makes DeepEqualWith consider two NaNs equal.
+func NaNEqual() EqualOption {
+	return func(s *equalState) {
+		s.nanEqual = true
+	}
+}
+
+// NilEqualsEmpty makes DeepEqualWith consider nil slices and maps equal to empty, non nil ones.
+func NilEqualsEmpty() EqualOption {
+	return func(s *equalState) {
+		s.nilEqualsEmpty = true
+	}
+}
@@ -75,6 +75,11 @@ func typedslicecopy(elemType *RType, dst, src sliceHeader) int
 //go:linkname memclrNoHeapPointers reflect.memclrNoHeapPointers
 func memclrNoHeapPointers(ptr unsafe.Pointer, n uintptr)
 
+// typedmemclr zeros the value at ptr of type t.
+//go:noescape
+//go:linkname typedmemclr reflect.typedmemclr
+func typedmemclr(t *RType, ptr unsafe.Pointer)
+
 //go:linkname ifaceE2I reflect.ifaceE2I
 func ifaceE2I(t *RType, src interface{}, dst unsafe.Pointer)
 
@@ -123,6 +128,10 @@ func mapdelete(t *RType, m unsafe.Pointer, key unsafe.Pointer)
 //go:linkname mapiterkey reflect.mapiterkey
 func mapiterkey(it unsafe.Pointer) (key unsafe.Pointer)
 
+//go:noescape
+//go:linkname mapitervalue reflect.mapitervalue
+func mapitervalue(it unsafe.Pointer) (value unsafe.Pointer)
+
 //go:noescape
 //go:linkname mapiternext reflect.mapiternext
 func mapiternext(it unsafe.Pointer)
@@ -131,6 +140,40 @@ func mapiternext(it unsafe.Pointer)
 //go:linkname maplen reflect.maplen
 func maplen(m unsafe.Pointer) int
 
+/////////////////// Chans ////////////
+
+//go:noescape
+//go:linkname chancap reflect.chancap
+func chancap(ch unsafe.Pointer) int
+
+//go:noescape
+//go:linkname chanclose reflect.chanclose
+func chanclose(ch unsafe.Pointer)
+
+//go:noescape
+//go:linkname chanlen reflect.chanlen
+func chanlen(ch unsafe.Pointer) int
+
+// Note: the noescape annotations below are technically a lie, but safe in the context of this package.
+// chansend and chanrecv don't escape the referent, but may escape anything the referent points to (they do shallow copies of the referent).
+//go:noescape
+//go:linkname chanrecv reflect.chanrecv
+func chanrecv(ch unsafe.Pointer, nb bool, val unsafe.Pointer) (selected, received bool)
+
+//go:noescape
+//go:linkname chansend reflect.chansend
+func chansend(ch unsafe.Pointer, val unsafe.Pointer, nb bool) bool
+
+//go:linkname makechan reflect.makechan
+func makechan(t *RType, size int) (ch unsafe.Pointer)
+
+// rselect runs a select. It returns the index of the chosen case.
+// If the case was a receive, val is filled in with the received value.
+// The conventional OK bool indicates whether the receive corresponds to a sent value.
+//go:noescape
+//go:linkname rselect reflect.rselect
+func rselect([]runtimeSelect) (chosen int, recvOK bool)
+
 // stubFunction is an assembly function that is the code half of
 // the function returned from MakeFunc. It expects a *callReflectFunc
 // as its context register, and its job is to invoke callReflect(ctxt, frame)
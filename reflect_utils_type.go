@@ -8,6 +8,8 @@ package reflect
 
 import (
 	"bytes"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -112,6 +114,18 @@ func emptyFuncProto() funcType {
 	return *prototype
 }
 
+func emptyChanProto() chanType {
+	var ichan interface{} = (chan unsafe.Pointer)(nil)
+	prototype := *(**chanType)(unsafe.Pointer(&ichan))
+	return *prototype
+}
+
+func emptyStructProto() structType {
+	var istruct interface{} = struct{}{}
+	prototype := *(**structType)(unsafe.Pointer(&istruct))
+	return *prototype
+}
+
 func emptySliceProto() sliceType {
 	var islice interface{} = ([]unsafe.Pointer)(nil)
 	prototype := *(**sliceType)(unsafe.Pointer(&islice))
@@ -147,7 +161,12 @@ func funcStr(ft *funcType) []byte {
 		if i > 0 {
 			result = append(result, ", "...)
 		}
-		result = append(result, t.nomen()...)
+		if ft.isVariadic() && i == int(ft.InLen)-1 {
+			result = append(result, "..."...)
+			result = append(result, t.ConvToSlice().ElemType.nomen()...)
+		} else {
+			result = append(result, t.nomen()...)
+		}
 	}
 	result = append(result, ')')
 	out := ft.outParams()
@@ -223,32 +242,10 @@ func lenExportedMethods(t *RType) int {
 }
 
 func methods(t *RType) ([]method, bool) {
-	if !t.hasInfoFlag() {
-		return nil, false
-	}
-
-	var ut *uncommonType
-	switch t.Kind() {
-	case Struct:
-		ut = &(*uncommonStruct)(unsafe.Pointer(t)).u
-	case Ptr:
-		ut = &(*uncommonPtr)(unsafe.Pointer(t)).u
-	case Func:
-		ut = &(*uncommonFunc)(unsafe.Pointer(t)).u
-	case Slice:
-		ut = &(*uncommonSlice)(unsafe.Pointer(t)).u
-	case Array:
-		ut = &(*uncommonArray)(unsafe.Pointer(t)).u
-	case Interface:
-		ut = &(*uncommonInterface)(unsafe.Pointer(t)).u
-	default:
-		ut = &(*uncommonConcrete)(unsafe.Pointer(t)).u
-	}
-
-	if ut.mCount == 0 {
+	ut := t.uncommon()
+	if ut == nil || ut.mCount == 0 {
 		return nil, false
 	}
-
 	return (*[1 << 16]method)(unsafe.Pointer(uintptr(unsafe.Pointer(ut)) + uintptr(ut.mOffset)))[:ut.mCount:ut.mCount], true
 }
 
@@ -359,6 +356,118 @@ func unhex(b byte) (v rune, ok bool) {
 	return
 }
 
+// from strconv - appendQuotedWith appends s to buf as a Go string literal delimited by quote.
+func appendQuotedWith(buf []byte, s string, quote byte, ASCIIonly bool) []byte {
+	buf = append(buf, quote)
+	for width := 0; len(s) > 0; s = s[width:] {
+		r := rune(s[0])
+		width = 1
+		if r >= utf8.RuneSelf {
+			r, width = utf8.DecodeRuneInString(s)
+		}
+		if width == 1 && r == utf8.RuneError {
+			buf = append(buf, '\\', 'x', lowerHex[s[0]>>4], lowerHex[s[0]&0xF])
+			continue
+		}
+		buf = appendEscapedRune(buf, r, quote, ASCIIonly)
+	}
+	buf = append(buf, quote)
+	return buf
+}
+
+// from strconv
+func appendEscapedRune(buf []byte, r rune, quote byte, ASCIIonly bool) []byte {
+	var runeTmp [utf8.UTFMax]byte
+	if r == rune(quote) || r == '\\' { // always backslashed
+		buf = append(buf, '\\', byte(r))
+		return buf
+	}
+	if ASCIIonly {
+		if r < utf8.RuneSelf && unicode.IsPrint(r) {
+			buf = append(buf, byte(r))
+			return buf
+		}
+	} else if unicode.IsPrint(r) {
+		n := utf8.EncodeRune(runeTmp[:], r)
+		buf = append(buf, runeTmp[:n]...)
+		return buf
+	}
+	switch r {
+	case '\a':
+		buf = append(buf, `\a`...)
+	case '\b':
+		buf = append(buf, `\b`...)
+	case '\f':
+		buf = append(buf, `\f`...)
+	case '\n':
+		buf = append(buf, `\n`...)
+	case '\r':
+		buf = append(buf, `\r`...)
+	case '\t':
+		buf = append(buf, `\t`...)
+	case '\v':
+		buf = append(buf, `\v`...)
+	default:
+		switch {
+		case r < ' ':
+			buf = append(buf, '\\', 'x', lowerHex[byte(r)>>4], lowerHex[byte(r)&0xF])
+		case r > utf8.MaxRune:
+			r = 0xFFFD
+			fallthrough
+		case r < 0x10000:
+			buf = append(buf, `\u`...)
+			for s := 12; s >= 0; s -= 4 {
+				buf = append(buf, lowerHex[r>>uint(s)&0xF])
+			}
+		default:
+			buf = append(buf, `\U`...)
+			for s := 28; s >= 0; s -= 4 {
+				buf = append(buf, lowerHex[r>>uint(s)&0xF])
+			}
+		}
+	}
+	return buf
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// isValidFieldName checks if a string is a valid (struct) field name or not.
+//
+// According to the language spec, a field name should be an identifier.
+//
+// identifier = letter { letter | unicode_digit } .
+// letter = unicode_letter | "_" .
+func isValidFieldName(fieldName string) bool {
+	for i, c := range fieldName {
+		if i == 0 && !isLetter(c) {
+			return false
+		}
+		if !(isLetter(c) || unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return len(fieldName) > 0
+}
+
+// typePtrData returns the length in bytes of the prefix of a struct type
+// containing pointer data. Anything after this offset is scalar data.
+func typePtrData(st *structType) uintptr {
+	// find the last field that has pointers.
+	field := -1
+	for i := range st.fields {
+		if st.fields[i].Type.hasPointers() {
+			field = i
+		}
+	}
+	if field == -1 {
+		return 0
+	}
+	f := &st.fields[field]
+	return structFieldOffset(f) + f.Type.ptrData
+}
+
 // methodName returns the name of the calling method,
 // assumed to be two stack frames above.
 /**
@@ -372,6 +481,29 @@ func methodName() string {
 }
 **/
 
+// appendUint appends the digits of u, in the given base (2 to 16), to buf.
+func appendUint(buf []byte, u uint64, base int) []byte {
+	var b [64]byte
+	bp := len(b)
+	for b64 := uint64(base); u >= b64; {
+		bp--
+		q := u / b64
+		b[bp] = lowerHex[u-q*b64]
+		u = q
+	}
+	bp--
+	b[bp] = lowerHex[u]
+	return append(buf, b[bp:]...)
+}
+
+// appendInt appends the decimal form of i to buf.
+func appendInt(buf []byte, i int64) []byte {
+	if i < 0 {
+		return appendUint(append(buf, '-'), uint64(-i), 10)
+	}
+	return appendUint(buf, uint64(i), 10)
+}
+
 // Cheap integer to fixed-width decimal ASCII. Give a negative width to avoid zero-padding - Found in "log" package
 func I2A(i int, wid int) string {
 	// Assemble decimal in reverse order.
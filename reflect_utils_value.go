@@ -44,6 +44,13 @@ func makeInt(f Flag, bits uint64, t *RType) Value {
 	return Value{Type: t, Ptr: newPtr, Flag: f | pointerFlag | Flag(t.Kind())}
 }
 
+// makeBool returns a Value of type t equal to b, where t is a bool type.
+func makeBool(f Flag, b bool, t *RType) Value {
+	newPtr := unsafeNew(t)
+	*(*bool)(newPtr) = b
+	return Value{Type: t, Ptr: newPtr, Flag: f | pointerFlag | Flag(t.Kind())}
+}
+
 // makeFloat returns a Value of type t equal to v (possibly truncated to float32),
 // where t is a float32 or float64 type.
 func makeFloat(f Flag, v float64, t *RType) Value {
@@ -304,6 +311,36 @@ func cvtDirect(v Value, typ *RType) Value {
 	return Value{Type: typ, Ptr: valPtr, Flag: v.ro() | f}
 }
 
+// convert operation: slice -> pointer to array, sharing the memory of the slice
+func cvtSliceArrayPtr(v Value, typ *RType) Value {
+	n := sliceArrayLen(typ)
+	header := (*sliceHeader)(v.Ptr)
+	if n > header.Len {
+		panic("reflect.Value.Convert: cannot convert slice with length " + I2A(header.Len, -1) + " to pointer to array with length " + I2A(n, -1))
+	}
+	return Value{Type: typ, Ptr: header.Data, Flag: v.ro() | Flag(Ptr)}
+}
+
+// convert operation: slice -> array, copying the elements
+func cvtSliceArray(v Value, typ *RType) Value {
+	n := sliceArrayLen(typ)
+	header := (*sliceHeader)(v.Ptr)
+	if n > header.Len {
+		panic("reflect.Value.Convert: cannot convert slice with length " + I2A(header.Len, -1) + " to array with length " + I2A(n, -1))
+	}
+	c := unsafeNew(typ)
+	typedmemmove(typ, c, header.Data)
+	return Value{Type: typ, Ptr: c, Flag: v.ro() | pointerFlag | Flag(Array)}
+}
+
+// sliceArrayLen returns the length of the array type typ, or of the array pointed by typ.
+func sliceArrayLen(typ *RType) int {
+	if typ.Kind() == Ptr {
+		typ = typ.Deref()
+	}
+	return int(typ.ConvToArray().Len)
+}
+
 // convert operation: concrete -> interface
 func cvtT2I(v Value, typ *RType) Value {
 	target := unsafeNew(typ)
@@ -465,3 +502,32 @@ func funcName(f func([]Value) []Value) string {
 	}
 	return "closure"
 }
+
+// isZeroMemory reports whether the size bytes at p are all zero, checking a word at a time when possible.
+func isZeroMemory(p unsafe.Pointer, size uintptr) bool {
+	offset := uintptr(0)
+	if uintptr(p)%PtrSize == 0 {
+		for ; offset+PtrSize <= size; offset += PtrSize {
+			if *(*uintptr)(add(p, offset)) != 0 {
+				return false
+			}
+		}
+	}
+	for ; offset < size; offset++ {
+		if *(*byte)(add(p, offset)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// copyVal returns a Value containing the map key or value at ptr,
+// allocating a new variable as needed, so future changes to the map won't change the returned value.
+func copyVal(typ *RType, fl Flag, ptr unsafe.Pointer) Value {
+	if typ.isDirectIface() {
+		c := unsafeNew(typ)
+		typedmemmove(typ, c, ptr)
+		return Value{Type: typ, Ptr: c, Flag: fl | pointerFlag}
+	}
+	return Value{Type: typ, Ptr: convPtr(ptr), Flag: fl}
+}
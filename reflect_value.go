@@ -253,6 +253,73 @@ func (v Value) Set(toX Value) bool {
 	return true
 }
 
+// TrySet assigns x to the value v, like Set, but reports the reason of a failure instead of printing or panicking.
+func (v Value) TrySet(x Value) error {
+	if !v.IsValid() || !x.IsValid() {
+		return ErrInvalidValue
+	}
+	if !v.isExported() || !x.isExported() {
+		return ErrUnexported
+	}
+	if !v.CanAddr() || x.hasMethodFlag() || !x.Type.AssignableTo(v.Type) {
+		return ErrNotAssignable
+	}
+	v.Set(x)
+	return nil
+}
+
+// IsZero reports whether v is the zero value for its type : nil for chans, funcs, interfaces, maps, pointers and slices
+// (an empty but non nil slice or map is not zero), the empty string, false, 0 (but not -0.0), and arrays and structs
+// made of zero values. Values of types without pointers are compared in memory, at once.
+func (v Value) IsZero() bool {
+	if !v.IsValid() {
+		if willPrintDebug {
+			panic("reflect.Value.IsZero: call on zero Value")
+		}
+		return true
+	}
+	if !v.Type.hasPointers() {
+		// types without pointers are never stored directly in Ptr
+		return isZeroMemory(v.Ptr, v.Type.size)
+	}
+	switch v.Kind() {
+	case String:
+		return (*stringHeader)(v.Ptr).Len == 0
+	case Array:
+		items := ToSlice(v)
+		for i, n := 0, items.Len(); i < n; i++ {
+			if !items.Index(i).IsZero() {
+				return false
+			}
+		}
+		return true
+	case Struct:
+		fields := ToStruct(v)
+		for i, n := 0, fields.NumField(); i < n; i++ {
+			if !fields.Field(i).IsZero() {
+				return false
+			}
+		}
+		return true
+	case UnsafePointer:
+		return v.pointer() == nil
+	}
+	return v.IsNil()
+}
+
+// SetZero sets v to the zero value of its type. As with Set, v must be settable.
+func (v Value) SetZero() bool {
+	if !v.IsValid() || !v.CanSet() {
+		if willPrintDebug {
+			panic("reflect.Value.SetZero : value is not settable.")
+		}
+		return false
+	}
+	// addressable values always hold a pointer to their data
+	typedmemclr(v.Type, v.Ptr)
+	return true
+}
+
 // pointer returns the underlying pointer represented by v.
 // v.Kind() must be ptr, Map, Chan, Func, or UnsafePointer
 func (v Value) pointer() unsafe.Pointer {
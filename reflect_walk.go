@@ -0,0 +1,125 @@
+/*
+ * Copyright 2009-2018 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package reflect
+
+import "unsafe"
+
+// Walk calls the visitor for v and, recursively, for the content of v : the value pointed by a pointer or held by an interface,
+// the fields of a struct (embedded ones being nodes too), the elements of a slice or an array and the entries of a map,
+// in the order of their keys. Enter is called before the children of a node and Leave after them.
+// Enter can return SkipChildren to go on with the next sibling, and both can return Stop to end the walk.
+// A pointer or a map met again while walking its children is marked as Cycle and its children are skipped.
+// Walk reports whether it walked everything, that is if the visitor did not stop it.
+func Walk(v Value, visitor Visitor) bool {
+	state := walkState{visitor: visitor}
+	state.walk(WalkNode{Value: v, Index: -1}, nil)
+	return !state.stopped
+}
+
+// Enter calls f.OnEnter, if not nil.
+func (f WalkFuncs) Enter(node *WalkNode) WalkAction {
+	if f.OnEnter == nil {
+		return WalkContinue
+	}
+	return f.OnEnter(node)
+}
+
+// Leave calls f.OnLeave, if not nil.
+func (f WalkFuncs) Leave(node *WalkNode) WalkAction {
+	if f.OnLeave == nil {
+		return WalkContinue
+	}
+	return f.OnLeave(node)
+}
+
+func (s *walkState) walk(node WalkNode, path []byte) {
+	node.Path = path
+	node.Kind = node.Value.Kind()
+
+	var key visit
+	if (node.Kind == Ptr || node.Kind == Map) && !node.Value.IsNil() {
+		key = visit{a1: node.Value.pointer(), typ: node.Value.Type}
+		node.Cycle = s.walking[key]
+	}
+
+	switch s.visitor.Enter(&node) {
+	case Stop:
+		s.stopped = true
+		return
+	case SkipChildren:
+	default:
+		if node.Cycle {
+			break
+		}
+		if key.a1 != nil {
+			if s.walking == nil {
+				s.walking = make(map[visit]bool)
+			}
+			s.walking[key] = true
+			s.children(&node)
+			delete(s.walking, key)
+		} else {
+			s.children(&node)
+		}
+		if s.stopped {
+			return
+		}
+	}
+
+	if s.visitor.Leave(&node) == Stop {
+		s.stopped = true
+	}
+}
+
+// children walks the children of node. They append to the path of node, one after another.
+func (s *walkState) children(node *WalkNode) {
+	v, depth := node.Value, node.Depth+1
+	switch node.Kind {
+	case Ptr:
+		if !v.IsNil() {
+			s.walk(WalkNode{Value: v.Deref(), Depth: depth, Index: -1}, node.Path)
+		}
+
+	case Interface:
+		if !v.IsNil() {
+			s.walk(WalkNode{Value: v.Iface(), Depth: depth, Index: -1}, node.Path)
+		}
+
+	case Struct:
+		fields := ToStruct(v)
+		fields.Fields(func(typ *RType, name []byte, tag []byte, pack []byte, embedded, exported bool, offset uintptr, index int, _ unsafe.Pointer) {
+			if s.stopped {
+				return
+			}
+			fieldPath := node.Path
+			if len(fieldPath) > 0 {
+				fieldPath = append(fieldPath, '.')
+			}
+			s.walk(WalkNode{Value: fields.Field(index), Depth: depth, Name: name, Tag: tag, Index: index}, append(fieldPath, name...))
+		})
+
+	case Slice, Array:
+		items := ToSlice(v)
+		for i, n := 0, items.Len(); i < n && !s.stopped; i++ {
+			elemPath := append(appendInt(append(node.Path, '['), int64(i)), ']')
+			s.walk(WalkNode{Value: items.Index(i), Depth: depth, Index: i}, elemPath)
+		}
+
+	case Map:
+		if v.IsNil() {
+			return
+		}
+		entries := ToMap(v)
+		for _, entry := range sortedDiffKeys(entries) {
+			if s.stopped {
+				return
+			}
+			entryPath := append(append(append(node.Path, '['), entry.label...), ']')
+			s.walk(WalkNode{Value: entries.MapIndex(entry.key), Depth: depth, Index: -1, Key: entry.key}, entryPath)
+		}
+	}
+}